@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/contents"
+	"github.com/hugo-sv/webmonitor/display"
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/metrics"
+	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/otlp"
+	"github.com/hugo-sv/webmonitor/statistics"
+	"github.com/hugo-sv/webmonitor/timeseries"
+)
+
+// burnAlert identifies the fast or slow burn rate alert of a service level objective
+type burnAlert struct {
+	objective *statistics.Objective
+	fast      bool
+}
+
+// raiseAlert appends an alert message, and updates the UI. The website of the alert is given by its URL, which is replaced by its label.
+func raiseAlert(uiView *display.View, alert catalog.Alert) {
+	alert.Time = time.Now()
+	alert.URL = uiView.Labels[alert.URL]
+	uiView.AlertMessages = append(uiView.AlertMessages, alert.Message)
+	if uiView.OnAlert != nil {
+		uiView.OnAlert(alert)
+	}
+	go display.RenderAlert(*uiView, alert)
+}
+
+// checkAvailability raises an alert when the availability of a website crosses the 80% threshold, and opens or closes its incident.
+// The key is the one of the website in the history.
+func checkAvailability(uiView *display.View, store *history.Store, key string, stats monitor.CheckStats, previousAvailability float64, currentAvailability float64) {
+	url := stats.URL
+	// If 80% threshold is crossed, or website is unavailable from the start
+	if currentAvailability < 0.8 && (previousAvailability >= 0.8 || math.IsNaN(previousAvailability)) {
+		raiseAlert(uiView, catalog.Alert{URL: url, Name: "availability", State: catalog.Fired, Message: fmt.Sprintf("Website %s is down. availability=%.0f %%, time=%v",
+			display.Shorten(uiView.Labels[url]),
+			currentAvailability*100.0,
+			time.Now().Format(time.Kitchen),
+		)})
+		if incident, opened := uiView.Incidents.Open(url, stats.Time); opened {
+			saveIncident(store, key, incident)
+		}
+	}
+	// If availability is back above the 80% threshold
+	if currentAvailability >= 0.8 && previousAvailability < 0.8 {
+		raiseAlert(uiView, catalog.Alert{URL: url, Name: "availability", State: catalog.Resolved, Message: fmt.Sprintf("Website %s is up, time=%v",
+			display.Shorten(uiView.Labels[url]),
+			time.Now().Format(time.Kitchen),
+		)})
+		if incident, closed := uiView.Incidents.Close(url, stats.Time); closed {
+			saveIncident(store, key, incident)
+			go display.RenderIncidentClosed(*uiView, incident)
+		}
+	}
+}
+
+// saveIncident records an incident of a website in the history, if any, under the key of the website
+func saveIncident(store *history.Store, key string, incident incidents.Incident) {
+	if store == nil {
+		return
+	}
+	incident.URL = key
+	store.SaveIncident(incident)
+}
+
+// checkBurnRates raises an alert when the error budget of a website's objective starts, or stops, burning too fast
+func checkBurnRates(uiView *display.View, url string, firing map[burnAlert]bool) {
+	for _, objective := range uiView.URLObjectives[url] {
+		for _, fast := range []bool{true, false} {
+			alert := burnAlert{objective, fast}
+			speed := "slow"
+			if fast {
+				speed = "fast"
+			}
+			burning, burnRate := objective.Burning(fast)
+			if burning && !firing[alert] {
+				raiseAlert(uiView, catalog.Alert{URL: url, Name: speed + "_burn", Objective: objective.Name, State: catalog.Fired, Message: fmt.Sprintf("Website %s SLO %s is burning its error budget (%s burn). burn rate=%.1fx, budget remaining=%.0f %%, time=%v",
+					display.Shorten(uiView.Labels[url]),
+					objective.Name,
+					speed,
+					burnRate,
+					objective.ErrorBudgetRemaining()*100.0,
+					time.Now().Format(time.Kitchen),
+				)})
+			}
+			if !burning && firing[alert] {
+				raiseAlert(uiView, catalog.Alert{URL: url, Name: speed + "_burn", Objective: objective.Name, State: catalog.Resolved, Message: fmt.Sprintf("Website %s SLO %s %s burn is over, time=%v",
+					display.Shorten(uiView.Labels[url]),
+					objective.Name,
+					speed,
+					time.Now().Format(time.Kitchen),
+				)})
+			}
+			firing[alert] = burning
+		}
+	}
+}
+
+// checkAnomaly raises an alert when the response times of a website start, or stop, deviating from their baseline
+func checkAnomaly(uiView *display.View, stats monitor.CheckStats, detector *statistics.AnomalyDetector, wasAnomalous bool) {
+	anomalous := detector.Anomalous()
+	if anomalous && !wasAnomalous {
+		mean, deviation := detector.Baseline()
+		raiseAlert(uiView, catalog.Alert{URL: stats.URL, Name: "anomaly", State: catalog.Fired, Message: fmt.Sprintf("Website %s response time is anomalous. response time=%dms, baseline=%.0fms ± %.0fms, time=%v",
+			display.Shorten(uiView.Labels[stats.URL]),
+			stats.ResponseTime,
+			mean,
+			detector.Sigmas*deviation,
+			time.Now().Format(time.Kitchen),
+		)})
+	}
+	if !anomalous && wasAnomalous {
+		raiseAlert(uiView, catalog.Alert{URL: stats.URL, Name: "anomaly", State: catalog.Resolved, Message: fmt.Sprintf("Website %s response time is back to normal, time=%v",
+			display.Shorten(uiView.Labels[stats.URL]),
+			time.Now().Format(time.Kitchen),
+		)})
+	}
+}
+
+// checkContent raises an alert when the content of a website changes, resolved once it is back to its expected content, if any
+func checkContent(uiView *display.View, stats monitor.CheckStats, watcher *contents.Watcher) {
+	change, changed := watcher.Check(stats.Time, stats.Body)
+	if !changed {
+		return
+	}
+	alert := catalog.Alert{URL: stats.URL, Name: "content", State: catalog.Fired, Message: fmt.Sprintf("Website %s content changed. hash=%.12s, previous=%.12s, time=%v",
+		display.Shorten(uiView.Labels[stats.URL]),
+		change.Current.Hash,
+		change.Previous.Hash,
+		time.Now().Format(time.Kitchen),
+	)}
+	if change.Expected {
+		alert.State = catalog.Resolved
+		alert.Message = fmt.Sprintf("Website %s content is back to the expected one, time=%v",
+			display.Shorten(uiView.Labels[stats.URL]),
+			time.Now().Format(time.Kitchen),
+		)
+	}
+	raiseAlert(uiView, alert)
+}
+
+// exportPoints adds a point to every time series database exporter
+func exportPoints(exporters []*timeseries.Exporter, point timeseries.Point) {
+	for _, exporter := range exporters {
+		exporter.Add(point)
+	}
+}
+
+// exportSpans adds the spans of a traced check to every trace exporter. Websites are exported by their label, as failure reasons are redacted already.
+func exportSpans(exporters []*otlp.TraceExporter, stats monitor.CheckStats, label string, tags map[string]string) {
+	spans := otlp.CheckSpans(label, tags, stats)
+	for _, exporter := range exporters {
+		exporter.Add(spans...)
+	}
+}
+
+// exportMetrics records a check and the alert states of its website in the metrics
+func exportMetrics(exporter *metrics.Exporter, stats monitor.CheckStats, availability float64, objectives []*statistics.Objective, firing map[burnAlert]bool, detector *statistics.AnomalyDetector) {
+	exporter.Record(stats.URL, stats.Time, stats.ResponseTime, stats.StatusCode)
+	exporter.SetAlert(stats.URL, "availability", "", availability < 0.8)
+	for _, objective := range objectives {
+		exporter.SetAlert(stats.URL, "fast_burn", objective.Name, firing[burnAlert{objective, true}])
+		exporter.SetAlert(stats.URL, "slow_burn", objective.Name, firing[burnAlert{objective, false}])
+	}
+	if detector != nil {
+		exporter.SetAlert(stats.URL, "anomaly", "", detector.Anomalous())
+	}
+}
+
+// checkExport raises an alert when a time series database exporter starts, or stops, failing to send its points
+func checkExport(uiView *display.View, failure timeseries.Failure) {
+	if failure.Err != nil {
+		raiseAlert(uiView, catalog.Alert{Name: "export", State: catalog.Fired, Message: cli.Redact(fmt.Sprintf("Exporter %s is failing, points are buffered. error=%v, time=%v",
+			failure.Exporter,
+			failure.Err,
+			time.Now().Format(time.Kitchen),
+		))})
+		return
+	}
+	raiseAlert(uiView, catalog.Alert{Name: "export", State: catalog.Resolved, Message: cli.Redact(fmt.Sprintf("Exporter %s recovered, time=%v",
+		failure.Exporter,
+		time.Now().Format(time.Kitchen),
+	))})
+}
+
+// checkHistory raises an alert when the history file starts, or stops, failing to be written
+func checkHistory(uiView *display.View, failure history.Failure) {
+	if failure.Err != nil {
+		raiseAlert(uiView, catalog.Alert{Name: "history", State: catalog.Fired, Message: cli.Redact(fmt.Sprintf("History file cannot be written, checks are kept meanwhile. error=%v, time=%v",
+			failure.Err,
+			time.Now().Format(time.Kitchen),
+		))})
+		return
+	}
+	raiseAlert(uiView, catalog.Alert{Name: "history", State: catalog.Resolved, Message: fmt.Sprintf("History file written again, %d checks were dropped. time=%v",
+		failure.Dropped,
+		time.Now().Format(time.Kitchen),
+	)})
+}
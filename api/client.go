@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/cli"
+)
+
+// Client requests the API of a running webmonitor
+type Client struct {
+	// Base URL of the API, such as http://localhost:8080
+	base  string
+	token string
+	http  http.Client
+}
+
+// NewClient returns a new Client of the API served on an address such as localhost:8080, authenticating with the token, if any
+func NewClient(addr string, token string) *Client {
+	base := strings.TrimSuffix(addr, "/")
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "http://" + base
+	}
+	return &Client{base: base, token: token, http: http.Client{Timeout: 10 * time.Second}}
+}
+
+// Websites returns the monitored websites
+func (c *Client) Websites() ([]Website, error) {
+	var websites []Website
+	err := c.do(http.MethodGet, "/api/websites", nil, &websites)
+	return websites, err
+}
+
+// Add adds a website to monitor
+func (c *Client) Add(website cli.Website) (Website, error) {
+	var added Website
+	err := c.do(http.MethodPost, "/api/websites", website, &added)
+	return added, err
+}
+
+// Pause pauses the checks of a website
+func (c *Client) Pause(id int) (Website, error) {
+	return c.action(id, "pause")
+}
+
+// Resume resumes the checks of a website
+func (c *Client) Resume(id int) (Website, error) {
+	return c.action(id, "resume")
+}
+
+// Check checks a website right away
+func (c *Client) Check(id int) (Website, error) {
+	return c.action(id, "check")
+}
+
+// Remove stops monitoring a website
+func (c *Client) Remove(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/api/websites/%d", id), nil, nil)
+}
+
+// action requests an action on a website
+func (c *Client) action(id int, action string) (Website, error) {
+	var website Website
+	err := c.do(http.MethodPost, fmt.Sprintf("/api/websites/%d/%s", id, action), nil, &website)
+	return website, err
+}
+
+// do sends a request with a JSON body, if any, and decodes its JSON response into value, if any
+func (c *Client) do(method string, path string, body interface{}, value interface{}) error {
+	var buffer bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buffer).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.base+path, &buffer)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		var e errorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			return fmt.Errorf("%s %s : %s", method, path, resp.Status)
+		}
+		return fmt.Errorf("%s", e.Error)
+	}
+	if value == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(value)
+}
@@ -0,0 +1,379 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// maxAlerts is the number of most recent alerts kept
+const maxAlerts = 1000
+
+// Command is a management request of a website, handled by the monitor
+type Command struct {
+	// add, pause, resume, check or remove
+	Action string
+	URL    string
+	// Configuration of the added website, or of the website the action is about
+	Website cli.Website
+	// Receives the error of the command, nil if it succeeded
+	Done chan error
+}
+
+// Server serves the statistics, alerts and incidents of the websites as JSON, and forwards their management requests to the monitor
+type Server struct {
+	mutex      sync.Mutex
+	timeframes []time.Duration
+	// Monitored websites, in the order they were added
+	websites  []catalog.Website
+	incidents *incidents.Tracker
+	// Alerts, from the oldest to the most recent
+	alerts []catalog.Alert
+	// Bearer token required by the management requests, which are refused if it is empty
+	token    string
+	commands chan Command
+	mux      *http.ServeMux
+}
+
+// Website describes a website
+type Website struct {
+	ID          int               `json:"id"`
+	URL         string            `json:"url"`
+	Interval    int               `json:"interval"`
+	ApdexTarget int               `json:"apdexTarget"`
+	Tags        map[string]string `json:"tags"`
+	Paused      bool              `json:"paused"`
+	// Windows of the statistics, such as 2min or 1h
+	Windows []string `json:"windows"`
+}
+
+// statsResponse is the statistics of a website over a window
+type statsResponse struct {
+	ID      int     `json:"id"`
+	URL     string  `json:"url"`
+	Window  string  `json:"window"`
+	Seconds float64 `json:"windowSeconds"`
+	statistics.Snapshot
+	Objectives []statistics.ObjectiveSnapshot `json:"objectives,omitempty"`
+}
+
+// incidentResponse describes an incident, whose end is null while it is ongoing
+type incidentResponse struct {
+	ID           int        `json:"id"`
+	URL          string     `json:"url"`
+	Start        time.Time  `json:"start"`
+	End          *time.Time `json:"end"`
+	Ongoing      bool       `json:"ongoing"`
+	Duration     float64    `json:"durationSeconds"`
+	Checks       int        `json:"checks"`
+	FirstFailure string     `json:"firstFailure"`
+}
+
+// errorResponse is the body of a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewServer returns a new Server, serving the statistics of the given timeframes and the incidents of a tracker.
+// Management requests require the token, and are refused if it is empty.
+func NewServer(timeframes []time.Duration, tracker *incidents.Tracker, token string) *Server {
+	s := &Server{
+		timeframes: timeframes,
+		incidents:  tracker,
+		token:      token,
+		commands:   make(chan Command),
+		mux:        http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/api/websites", s.handleWebsites)
+	s.mux.HandleFunc("/api/websites/", s.handleWebsite)
+	s.mux.HandleFunc("/api/alerts", s.handleAlerts)
+	s.mux.HandleFunc("/api/incidents", s.handleIncidents)
+	return s
+}
+
+// Commands returns the channel of the management requests, each of them should be answered on its Done channel
+func (s *Server) Commands() <-chan Command {
+	return s.commands
+}
+
+// SetWebsites sets the monitored websites, in their order
+func (s *Server) SetWebsites(websites []catalog.Website) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.websites = websites
+}
+
+// AddAlert adds a raised alert to the Server. Only the most recent alerts are kept.
+func (s *Server) AddAlert(alert catalog.Alert) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.alerts = append(s.alerts, alert)
+	if len(s.alerts) > maxAlerts {
+		s.alerts = append(s.alerts[:0], s.alerts[len(s.alerts)-maxAlerts:]...)
+	}
+}
+
+// ServeHTTP serves the API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && s.token == "" {
+		writeError(w, http.StatusForbidden, "management requests are disabled, run webmonitor with -api-token to enable them")
+		return
+	}
+	if r.Method != http.MethodGet && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "a valid bearer token is required")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized returns whether a request carries the token
+func (s *Server) authorized(r *http.Request) bool {
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+// website returns the description of a website
+func (s *Server) website(site catalog.Website) Website {
+	tags := site.Config.Tags
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	windows := make([]string, 0, len(s.timeframes))
+	for _, timeframe := range s.timeframes {
+		windows = append(windows, cli.FormatDuration(timeframe))
+	}
+	return Website{
+		ID:          site.ID,
+		URL:         site.Label,
+		Interval:    site.Config.Interval,
+		ApdexTarget: site.Config.ApdexTarget,
+		Tags:        tags,
+		Paused:      site.Paused,
+		Windows:     windows,
+	}
+}
+
+// lookup returns the website of an id. The mutex should be held.
+func (s *Server) lookup(id string) (catalog.Website, bool) {
+	for _, site := range s.websites {
+		if strconv.Itoa(site.ID) == id {
+			return site, true
+		}
+	}
+	return catalog.Website{}, false
+}
+
+// handleWebsites serves the list of the websites, and adds a website
+func (s *Server) handleWebsites(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		websites := make([]Website, 0, len(s.websites))
+		for _, site := range s.websites {
+			websites = append(websites, s.website(site))
+		}
+		writeJSON(w, http.StatusOK, websites)
+	case http.MethodPost:
+		s.handleAdd(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+	}
+}
+
+// handleAdd adds the website configured in the body of a request
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	var website cli.Website
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&website); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid website : %v", err)
+		return
+	}
+	if parsed, err := url.Parse(website.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		writeError(w, http.StatusBadRequest, "invalid website : the URL should be an absolute http or https URL")
+		return
+	}
+	if err := cli.ParseWebsite(&website); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid website : %v", err)
+		return
+	}
+	s.mutex.Lock()
+	site, duplicate := catalog.Find(s.websites, website.URL)
+	s.mutex.Unlock()
+	if duplicate {
+		writeError(w, http.StatusConflict, "website %s is already monitored", site.Label)
+		return
+	}
+	s.command(w, Command{Action: "add", URL: website.URL, Website: website}, http.StatusCreated)
+}
+
+// websiteRoutes are the actions on a website, by method and path following /api/websites/{id}/
+var websiteRoutes = map[string]string{
+	"DELETE ":     "remove",
+	"GET stats":   "stats",
+	"POST pause":  "pause",
+	"POST resume": "resume",
+	"POST check":  "check",
+}
+
+// handleWebsite serves /api/websites/{id}/stats?window=10min, the statistics of a website over one of the timeframes, the first one by default.
+// It removes a website on DELETE /api/websites/{id}, and pauses, resumes or checks it on POST /api/websites/{id}/pause, resume or check.
+func (s *Server) handleWebsite(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/websites/"), "/", 2)
+	resource := ""
+	if len(parts) == 2 {
+		resource = parts[1]
+	}
+	action, ok := websiteRoutes[r.Method+" "+resource]
+	if !ok {
+		for route := range websiteRoutes {
+			if strings.HasSuffix(route, " "+resource) {
+				writeError(w, http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "%s not found", r.URL.Path)
+		return
+	}
+	s.mutex.Lock()
+	site, ok := s.lookup(parts[0])
+	if !ok {
+		s.mutex.Unlock()
+		writeError(w, http.StatusNotFound, "website %s not found", parts[0])
+		return
+	}
+	if action != "stats" {
+		s.mutex.Unlock()
+		status := http.StatusOK
+		switch action {
+		case "check":
+			status = http.StatusAccepted
+		case "remove":
+			status = http.StatusNoContent
+		}
+		s.command(w, Command{Action: action, URL: site.URL, Website: site.Config}, status)
+		return
+	}
+	defer s.mutex.Unlock()
+	timeframe := 0
+	if window := r.URL.Query().Get("window"); window != "" {
+		duration, err := cli.ParseDuration(window)
+		timeframe = -1
+		for index, t := range s.timeframes {
+			if err == nil && t == duration {
+				timeframe = index
+			}
+		}
+		if timeframe < 0 {
+			writeError(w, http.StatusBadRequest, "window %q is not one of the timeframes", window)
+			return
+		}
+	}
+	response := statsResponse{
+		ID:       site.ID,
+		URL:      site.Label,
+		Window:   cli.FormatDuration(s.timeframes[timeframe]),
+		Seconds:  s.timeframes[timeframe].Seconds(),
+		Snapshot: site.Statistics[timeframe].Snapshot(),
+	}
+	for _, objective := range site.Objectives {
+		response.Objectives = append(response.Objectives, objective.Snapshot())
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// command forwards a management request to the monitor, and responds with the website once it is handled
+func (s *Server) command(w http.ResponseWriter, command Command, status int) {
+	command.Done = make(chan error, 1)
+	s.commands <- command
+	if err := <-command.Done; err != nil {
+		writeError(w, http.StatusConflict, "%v", err)
+		return
+	}
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	site, ok := catalog.Find(s.websites, command.URL)
+	if !ok {
+		writeError(w, http.StatusNotFound, "website %s not found", command.Website.Label)
+		return
+	}
+	writeJSON(w, status, s.website(site))
+}
+
+// handleAlerts serves the alerts, from the most recent to the oldest
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	alerts := make([]catalog.Alert, 0, len(s.alerts))
+	for index := len(s.alerts) - 1; index >= 0; index-- {
+		alerts = append(alerts, s.alerts[index])
+	}
+	writeJSON(w, http.StatusOK, alerts)
+}
+
+// handleIncidents serves the incidents of every website, from the most recent to the oldest
+func (s *Server) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	response := make([]incidentResponse, 0)
+	for _, site := range s.websites {
+		for _, incident := range s.incidents.Incidents(site.URL) {
+			item := incidentResponse{
+				ID:           site.ID,
+				URL:          site.Label,
+				Start:        incident.Start,
+				Ongoing:      incident.Ongoing(),
+				Duration:     incident.Duration(now).Seconds(),
+				Checks:       len(incident.Samples),
+				FirstFailure: incident.FirstFailure,
+			}
+			if !incident.Ongoing() {
+				end := incident.End
+				item.End = &end
+			}
+			response = append(response, item)
+		}
+	}
+	sort.SliceStable(response, func(i, j int) bool {
+		return response[i].Start.After(response[j].Start)
+	})
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	writeJSON(w, status, errorResponse{Error: fmt.Sprintf(format, a...)})
+}
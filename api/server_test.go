@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// get requests the Server, and decodes its JSON response
+func get(t *testing.T, s *Server, method string, target string, value interface{}) int {
+	return request(t, s, method, target, "", "", value)
+}
+
+// request requests the Server with a token and a body, if any, and decodes its JSON response
+func request(t *testing.T, s *Server, method string, target string, token string, body string, value interface{}) int {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, req)
+	if recorder.Code == http.StatusNoContent {
+		return recorder.Code
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("%s %s Content-Type == %q, want application/json", method, target, contentType)
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(value); err != nil {
+		t.Errorf("%s %s returned invalid JSON : %v", method, target, err)
+	}
+	return recorder.Code
+}
+
+func TestServer(t *testing.T) {
+	now := time.Now()
+	timeframes := []time.Duration{2 * time.Minute, 10 * time.Minute}
+	tracker := incidents.NewTracker(2 * time.Minute)
+	s := NewServer(timeframes, tracker, "")
+	urls := []string{"https://example.com", "https://example.org"}
+	var monitored []catalog.Website
+	for id, url := range urls {
+		urlStatistics := []*statistics.Statistic{statistics.NewStatistic(timeframes[0], 500), statistics.NewStatistic(timeframes[1], 500)}
+		monitored = append(monitored, catalog.Website{ID: id, URL: url, Label: url, Config: cli.Website{URL: url, Interval: 5, ApdexTarget: 500, Tags: map[string]string{"env": "prod"}}, Statistics: urlStatistics})
+		// The first website answers in 100ms, the second one fails once
+		urlStatistics[1].AddRecord(now.Add(-5*time.Minute), 100, 200)
+		urlStatistics[0].AddRecord(now, 100*(id+1), 200)
+		urlStatistics[1].AddRecord(now, 100*(id+1), 200+300*id)
+	}
+	s.SetWebsites(monitored)
+	tracker.Record(urls[1], incidents.Sample{Time: now.Add(-time.Hour), StatusCode: 500, Reason: "500 Internal Server Error"})
+	tracker.Open(urls[1], now.Add(-time.Hour))
+	tracker.Close(urls[1], now.Add(-30*time.Minute))
+	tracker.Open(urls[0], now)
+	s.AddAlert(catalog.Alert{Time: now.Add(-time.Minute), URL: urls[1], Name: "availability", State: catalog.Fired, Message: "down"})
+	s.AddAlert(catalog.Alert{Time: now, URL: urls[1], Name: "availability", State: catalog.Resolved, Message: "up"})
+
+	var websites []Website
+	if code := get(t, s, "GET", "/api/websites", &websites); code != http.StatusOK || len(websites) != 2 {
+		t.Fatalf("GET /api/websites == %d, %v, want the 2 websites", code, websites)
+	}
+	if websites[1].ID != 1 || websites[1].URL != urls[1] || websites[1].Tags["env"] != "prod" || len(websites[1].Windows) != 2 || websites[1].Windows[1] != "10min" {
+		t.Errorf("GET /api/websites returned %+v", websites[1])
+	}
+
+	var stats statsResponse
+	if code := get(t, s, "GET", "/api/websites/1/stats?window=10min", &stats); code != http.StatusOK {
+		t.Fatalf("GET /api/websites/1/stats == %d", code)
+	}
+	if stats.Window != "10min" || stats.Checks != 2 || *stats.Availability != 0.5 || *stats.Max != 200 {
+		t.Errorf("GET /api/websites/1/stats?window=10min returned %+v", stats)
+	}
+	// The first timeframe by default, and windows can be written as durations
+	for _, target := range []string{"/api/websites/0/stats", "/api/websites/0/stats?window=2m"} {
+		stats = statsResponse{}
+		if code := get(t, s, "GET", target, &stats); code != http.StatusOK || stats.Window != "2min" || stats.Checks != 1 || *stats.Average != 100 {
+			t.Errorf("GET %s == %d, %+v", target, code, stats)
+		}
+	}
+
+	var alerts []catalog.Alert
+	if code := get(t, s, "GET", "/api/alerts", &alerts); code != http.StatusOK || len(alerts) != 2 || alerts[0].State != "resolved" {
+		t.Errorf("GET /api/alerts == %d, %+v, want the 2 alerts from the most recent", code, alerts)
+	}
+
+	var response []incidentResponse
+	if code := get(t, s, "GET", "/api/incidents", &response); code != http.StatusOK || len(response) != 2 {
+		t.Fatalf("GET /api/incidents == %d, %+v, want 2 incidents", code, response)
+	}
+	if !response[0].Ongoing || response[0].End != nil || response[0].ID != 0 {
+		t.Errorf("GET /api/incidents returned %+v first, want the ongoing incident", response[0])
+	}
+	if response[1].Ongoing || response[1].Duration != 1800 || response[1].FirstFailure != "500 Internal Server Error" {
+		t.Errorf("GET /api/incidents returned %+v second, want the closed incident", response[1])
+	}
+
+	// Errors
+	for _, c := range []struct {
+		method string
+		target string
+		code   int
+	}{
+		{"GET", "/api/websites/2/stats", http.StatusNotFound},
+		{"GET", "/api/websites/first/stats", http.StatusNotFound},
+		{"GET", "/api/websites/0/history", http.StatusNotFound},
+		{"GET", "/api/websites/0/stats?window=1h", http.StatusBadRequest},
+		{"GET", "/api/websites/0/stats?window=soon", http.StatusBadRequest},
+	} {
+		var e errorResponse
+		if code := get(t, s, c.method, c.target, &e); code != c.code || e.Error == "" {
+			t.Errorf("%s %s == %d, %+v, want %d and an error", c.method, c.target, code, e, c.code)
+		}
+	}
+}
+
+func TestServerCommands(t *testing.T) {
+	timeframes := []time.Duration{2 * time.Minute}
+	s := NewServer(timeframes, incidents.NewTracker(2*time.Minute), "secret")
+	monitored := []catalog.Website{{ID: 0, URL: "https://example.com", Label: "https://example.com", Config: cli.Website{URL: "https://example.com", Interval: 5, ApdexTarget: 500}, Statistics: []*statistics.Statistic{statistics.NewStatistic(timeframes[0], 500)}}}
+	s.SetWebsites(monitored)
+	// Handling the commands as the monitor would, giving the Server a new snapshot of the websites after each of them
+	var actions []string
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		nextID := 1
+		for {
+			select {
+			case command := <-s.Commands():
+				actions = append(actions, command.Action)
+				updated := make([]catalog.Website, 0, len(monitored)+1)
+				for _, site := range monitored {
+					switch {
+					case site.URL != command.URL:
+					case command.Action == "pause" || command.Action == "resume":
+						site.Paused = command.Action == "pause"
+					case command.Action == "remove":
+						continue
+					}
+					updated = append(updated, site)
+				}
+				if command.Action == "add" {
+					updated = append(updated, catalog.Website{ID: nextID, URL: command.URL, Label: command.URL, Config: command.Website, Statistics: []*statistics.Statistic{statistics.NewStatistic(timeframes[0], command.Website.ApdexTarget)}})
+					nextID++
+				}
+				monitored = updated
+				s.SetWebsites(monitored)
+				command.Done <- nil
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Management requests require the token, reads do not
+	var e errorResponse
+	for _, token := range []string{"", "wrong"} {
+		if code := request(t, s, "POST", "/api/websites/0/pause", token, "", &e); code != http.StatusUnauthorized {
+			t.Errorf("POST /api/websites/0/pause with token %q == %d, want %d", token, code, http.StatusUnauthorized)
+		}
+	}
+	var websites []Website
+	if code := get(t, s, "GET", "/api/websites", &websites); code != http.StatusOK {
+		t.Errorf("GET /api/websites without token == %d, want %d", code, http.StatusOK)
+	}
+
+	var website Website
+	if code := request(t, s, "POST", "/api/websites", "secret", `{"url": "https://example.org", "interval": 10, "tags": {"env": "prod"}}`, &website); code != http.StatusCreated {
+		t.Fatalf("POST /api/websites == %d, want %d", code, http.StatusCreated)
+	}
+	if website.ID != 1 || website.URL != "https://example.org" || website.Interval != 10 || website.ApdexTarget != 500 || website.Tags["env"] != "prod" {
+		t.Errorf("POST /api/websites returned %+v", website)
+	}
+	if code := request(t, s, "POST", "/api/websites/1/pause", "secret", "", &website); code != http.StatusOK || !website.Paused {
+		t.Errorf("POST /api/websites/1/pause == %d, %+v, want the paused website", code, website)
+	}
+	if code := request(t, s, "POST", "/api/websites/1/resume", "secret", "", &website); code != http.StatusOK || website.Paused {
+		t.Errorf("POST /api/websites/1/resume == %d, %+v, want the resumed website", code, website)
+	}
+	if code := request(t, s, "POST", "/api/websites/1/check", "secret", "", &website); code != http.StatusAccepted {
+		t.Errorf("POST /api/websites/1/check == %d, want %d", code, http.StatusAccepted)
+	}
+	if code := request(t, s, "DELETE", "/api/websites/0", "secret", "", nil); code != http.StatusNoContent {
+		t.Errorf("DELETE /api/websites/0 == %d, want %d", code, http.StatusNoContent)
+	}
+	websites = nil
+	if get(t, s, "GET", "/api/websites", &websites); len(websites) != 1 || websites[0].ID != 1 {
+		t.Errorf("GET /api/websites returned %+v, want the added website only", websites)
+	}
+	if len(actions) != 5 {
+		t.Errorf("the monitor received %v, want 5 commands", actions)
+	}
+
+	// Invalid requests are not forwarded
+	for _, c := range []struct {
+		method string
+		target string
+		body   string
+		code   int
+	}{
+		{"POST", "/api/websites", `{"url": "https://example.org", "interval": 5}`, http.StatusConflict},
+		{"POST", "/api/websites", `{"url": "ftp://example.net", "interval": 5}`, http.StatusBadRequest},
+		{"POST", "/api/websites", `{"url": "https://example.net", "interval": 0}`, http.StatusBadRequest},
+		{"POST", "/api/websites", `{"url": "https://example.net", "interval": 5, "retries": 3}`, http.StatusBadRequest},
+		{"POST", "/api/websites/0/pause", "", http.StatusNotFound},
+		{"PUT", "/api/websites", "", http.StatusMethodNotAllowed},
+		{"POST", "/api/websites/1/stats", "", http.StatusMethodNotAllowed},
+		{"POST", "/api/websites/1/restart", "", http.StatusNotFound},
+		{"GET", "/api/websites/1/pause", "", http.StatusMethodNotAllowed},
+		{"DELETE", "/api/websites/1/stats", "", http.StatusMethodNotAllowed},
+	} {
+		e = errorResponse{}
+		if code := request(t, s, c.method, c.target, "secret", c.body, &e); code != c.code || e.Error == "" {
+			t.Errorf("%s %s %s == %d, %+v, want %d and an error", c.method, c.target, c.body, code, e, c.code)
+		}
+	}
+	if len(actions) != 5 {
+		t.Errorf("the monitor received %v, want no more command", actions)
+	}
+}
+
+func TestServerWithoutToken(t *testing.T) {
+	// Without a token, management requests are refused rather than open to anyone reaching the port
+	s := NewServer([]time.Duration{2 * time.Minute}, incidents.NewTracker(2*time.Minute), "")
+	s.SetWebsites([]catalog.Website{{ID: 0, URL: "https://example.com", Label: "https://example.com", Config: cli.Website{URL: "https://example.com", Interval: 5, ApdexTarget: 500}, Statistics: []*statistics.Statistic{statistics.NewStatistic(2*time.Minute, 500)}}})
+	for _, c := range []struct {
+		method string
+		target string
+		body   string
+	}{
+		{"POST", "/api/websites", `{"url": "http://169.254.169.254/", "interval": 5}`},
+		{"POST", "/api/websites/0/check", ""},
+		{"DELETE", "/api/websites/0", ""},
+	} {
+		var e errorResponse
+		for _, token := range []string{"", "anything"} {
+			if code := request(t, s, c.method, c.target, token, c.body, &e); code != http.StatusForbidden || e.Error == "" {
+				t.Errorf("%s %s with token %q == %d, %+v, want %d and an error", c.method, c.target, token, code, e, http.StatusForbidden)
+			}
+		}
+	}
+	select {
+	case command := <-s.Commands():
+		t.Errorf("the monitor received %+v, want no command", command)
+	default:
+	}
+	var websites []Website
+	if code := get(t, s, "GET", "/api/websites", &websites); code != http.StatusOK || len(websites) != 1 {
+		t.Errorf("GET /api/websites == %d, %v, want the website", code, websites)
+	}
+}
@@ -0,0 +1,23 @@
+package catalog
+
+import "time"
+
+// States of an alert
+const (
+	Fired    = "fired"
+	Resolved = "resolved"
+)
+
+// Alert is an alert raised about a website
+type Alert struct {
+	Time time.Time `json:"time"`
+	// URL of the website, its secrets redacted
+	URL string `json:"url"`
+	// Kind of alert, such as availability or fast_burn
+	Name string `json:"alert"`
+	// Name of the service level objective the alert is about, if any
+	Objective string `json:"objective,omitempty"`
+	// Fired or Resolved
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
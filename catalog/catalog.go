@@ -0,0 +1,31 @@
+// Package catalog describes the monitored websites and their alerts, as the monitor shares them with the servers exposing them
+package catalog
+
+import (
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// Website is a monitored website. The monitor keeps the monitored websites, and gives the servers a snapshot of them each time they change.
+type Website struct {
+	// Id of the website, never reused within a run
+	ID  int
+	URL string
+	// URL with its secrets redacted, shown in place of it
+	Label  string
+	Config cli.Website
+	Paused bool
+	// Statistics of each timeframe
+	Statistics []*statistics.Statistic
+	Objectives []*statistics.Objective
+}
+
+// Find returns the website of a URL among websites
+func Find(websites []Website, url string) (Website, bool) {
+	for _, website := range websites {
+		if website.URL == url {
+			return website, true
+		}
+	}
+	return Website{}, false
+}
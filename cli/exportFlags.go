@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// ExportConfig is the parsed configuration of the webmonitor export command
+type ExportConfig struct {
+	HistoryPath string
+	// Exported website, by its key in the history
+	URL string
+	// Exported period
+	From time.Time
+	To   time.Time
+	// Output format : csv or json
+	Format string
+	// Path of the output file, empty for the standard output
+	OutPath string
+}
+
+// exportFormats are the output formats of the samples
+var exportFormats = map[string]bool{"csv": true, "json": true}
+
+// ParseExportFlags parse and returns the flags of the webmonitor export command. The boolean is false if they are invalid.
+func ParseExportFlags(args []string) (ExportConfig, bool) {
+	var config ExportConfig
+	var from, to, window string
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&config.HistoryPath, "history", "", "Path of the history file, overrides the JSON history path")
+	flags.StringVar(&config.URL, "url", "", "URL of the exported website")
+	flags.StringVar(&window, "window", "1h", "Duration of the exported period, such as 30m, 6h or 1d, ending at -to")
+	flags.StringVar(&from, "from", "", "Start of the period, as 2006-01-02 or RFC 3339, overrides -window")
+	flags.StringVar(&to, "to", "", "End of the period, as 2006-01-02 or RFC 3339 (default : now)")
+	flags.StringVar(&config.Format, "format", "csv", "Output format : csv or json")
+	flags.StringVar(&config.OutPath, "out", "", "Path of the output file (default : the standard output)")
+	flags.Parse(args)
+	var input JSONInput
+	var ok bool
+	if config.HistoryPath, input, ok = readConfigArgs(config.HistoryPath, flags.Args()); !ok {
+		return config, false
+	}
+	if config.URL == "" {
+		fmt.Println("No website specified, use -url")
+		return config, false
+	}
+	// The history records the websites by their key
+	config.URL = historyKey(input, config.URL)
+	if !exportFormats[config.Format] {
+		fmt.Printf("Unknown format %q, it should be csv or json\n", config.Format)
+		return config, false
+	}
+	// Parsing the period
+	var err error
+	config.To = time.Now()
+	if to != "" {
+		if config.To, err = parseDate(to); err != nil {
+			fmt.Println(err)
+			return config, false
+		}
+	}
+	duration, err := ParseDuration(window)
+	if err != nil || duration <= 0 {
+		fmt.Printf("Invalid window %q, it should be a positive duration such as 30m, 6h or 1d\n", window)
+		return config, false
+	}
+	config.From = config.To.Add(-duration)
+	if from != "" {
+		if config.From, err = parseDate(from); err != nil {
+			fmt.Println(err)
+			return config, false
+		}
+	}
+	if !config.From.Before(config.To) {
+		fmt.Println("The start of the period should be before its end")
+		return config, false
+	}
+	return config, true
+}
@@ -1,63 +1,624 @@
 package cli
 
 import (
+	"bufio"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/statuspage"
 )
 
 // JSONInput struct which contains an array of websites
 type JSONInput struct {
 	Timeout  int       `json:"timeout"`
 	Websites []Website `json:"websites"`
+	// Statistics timeframes, such as "5m", "1h" or "30d"
+	Timeframes []string      `json:"timeframes"`
+	History    *HistoryInput `json:"history"`
+	// Public status page generated from the history
+	StatusPage *statuspage.Config `json:"statusPage"`
+	// Time series databases the checks and statistics are exported to
+	Exporters []Exporter `json:"exporters"`
+}
+
+// HistoryInput struct which contains the path of the history file and its retention policy
+type HistoryInput struct {
+	Path string `json:"path"`
+	// How long raw records are kept before being downsampled to 1 minute rollups, such as "1d"
+	Raw string `json:"raw"`
+	// How long rollups are kept, such as "400d"
+	Rollups string `json:"rollups"`
 }
 
-// Website struct which contains an url and an interval
+// Website struct which contains an url, an interval, the headers to send and the service level objectives
 type Website struct {
-	URL      string `json:"url"`
-	Interval int    `json:"interval"`
+	URL      string            `json:"url"`
+	Interval int               `json:"interval"`
+	Headers  map[string]string `json:"headers"`
+	SLOs     []SLO             `json:"slos"`
+	// Apdex target response time in ms, defaults to 500
+	ApdexTarget int `json:"apdexTarget"`
+	// Response time anomaly detection, disabled if nil
+	Anomaly *Anomaly `json:"anomaly"`
+	// Content change detection, disabled if nil
+	Content *Content `json:"content"`
+	// Tags labelling the metrics of the website, such as {"env": "prod"}
+	Tags map[string]string `json:"tags"`
+	// URL as written in the JSON configuration, before its references were resolved
+	Template string `json:"-"`
+	// URL shown in place of the URL, its references replaced by ****
+	Label string `json:"-"`
+	// Values resolved in the URL and the headers, from the longest to the shortest
+	values []string
+}
+
+// Redact replaces the URL of the website found in s, such as in a failure reason, by its Label, and the values resolved in its URL and headers by ****
+func (w Website) Redact(s string) string {
+	if w.Label != "" {
+		s = strings.Replace(s, w.URL, w.Label, -1)
+	}
+	return redactValues(s, w.values)
+}
+
+// HistoryKey returns the key of a website in the history : its URL as written in the JSON configuration, which holds no secret
+// and stays the same when the secrets change, or its URL if it was given otherwise
+func (w Website) HistoryKey() string {
+	if w.Template != "" {
+		return w.Template
+	}
+	return w.URL
+}
+
+// historyKey returns the key in the history of a website given by its URL, either resolved or as written in the JSON configuration
+func historyKey(input JSONInput, url string) string {
+	for _, website := range input.Websites {
+		if website.URL == url || website.Template == url {
+			return website.HistoryKey()
+		}
+	}
+	return url
+}
+
+// Content struct which contains the settings of the content change detection : the hash of the response body is
+// compared to the previous one, or to an expected one.
+type Content struct {
+	// Regular expression of the volatile regions of the body removed before hashing, such as a timestamp
+	Ignore string `json:"ignore"`
+	// Expected SHA-256 of the body in hexadecimal, empty to compare each body with the previous one
+	Expected string `json:"expected"`
+	// Number of versions of the body kept for diffing, defaults to 5
+	Keep int `json:"keep"`
+	// Compiled ignore regular expression, nil to hash the whole body
+	IgnoreRegexp *regexp.Regexp `json:"-"`
+}
+
+// Anomaly struct which contains the settings of the response time anomaly detection : response times deviating from
+// their moving average by more than a number of standard deviations, for a sustained period, are anomalous.
+type Anomaly struct {
+	// Number of standard deviations, defaults to 3
+	Sigmas float64 `json:"sigmas"`
+	// Sustained period such as "2m", defaults to 2 minutes
+	Sustain string `json:"sustain"`
+	// Weight of a new check in the moving averages, strictly between 0 and 1, defaults to 0.05
+	Alpha float64 `json:"alpha"`
+	// Parsed sustained period
+	SustainDuration time.Duration `json:"-"`
+}
+
+// SLO struct which contains a service level objective : the percentage of good checks expected over a window.
+// A check is good if it succeeded, and its response time is at most the threshold, if any.
+type SLO struct {
+	Name      string  `json:"name"`
+	Objective float64 `json:"objective"`
+	// Window such as "30d", defaults to 30 days
+	Window string `json:"window"`
+	// Response time threshold in ms, 0 for an availability objective
+	Threshold int `json:"threshold"`
+	// Parsed window
+	WindowDuration time.Duration `json:"-"`
+}
+
+// Exporter struct which contains the settings of a time series database exporter : each check, and the statistics of
+// each timeframe when they are refreshed, are sent to it in batches.
+type Exporter struct {
+	// Type of the database : influxdb, statsd, graphite or otlp
+	Type string `json:"type"`
+	// InfluxDB write endpoint, such as http://localhost:8086/api/v2/write?org=o&bucket=b, or OTLP/HTTP collector
+	// endpoint, such as http://localhost:4318
+	URL string `json:"url"`
+	// Authorization header of the InfluxDB requests, such as "Token ${INFLUX_TOKEN}"
+	Authorization string `json:"authorization"`
+	// Headers of the OTLP requests, such as an API key
+	Headers map[string]string `json:"headers"`
+	// Whether each check is traced and exported as an OTLP span, with its traceparent header sent to the website
+	Traces bool `json:"traces"`
+	// File the InfluxDB points are appended to, rather than sent to a URL
+	Path string `json:"path"`
+	// StatsD or Graphite server address, such as localhost:8125 or localhost:2003
+	Address string `json:"address"`
+	// Prefix of the StatsD and Graphite metric paths, defaults to webmonitor
+	Prefix string `json:"prefix"`
+	// Number of points sent at once, defaults to 500
+	BatchSize int `json:"batchSize"`
+	// Longest time a point waits before being sent, such as "10s", defaults to 10 seconds
+	FlushInterval string `json:"flushInterval"`
+	// Number of points kept while the database fails, defaults to 10000
+	BufferSize int `json:"bufferSize"`
+	// Parsed flush interval
+	FlushIntervalDuration time.Duration `json:"-"`
+}
+
+// Config is the parsed configuration of the webmonitor cli command
+type Config struct {
+	Timeout int
+	// Monitored URLs, in the configuration order
+	Urls []string
+	// Website configuration of each URL
+	Websites map[string]Website
+	// Statistics timeframes, from the configuration order
+	Timeframes []time.Duration
+	// Path of the history file, empty if the history is disabled
+	HistoryPath      string
+	HistoryRetention history.Retention
+	UIEnabled        bool
+	// Address of the Prometheus metrics listener, empty if disabled
+	MetricsAddr string
+	// Address of the API listener, empty if disabled
+	APIAddr string
+	// Bearer token required by the API management requests, empty if they are not authenticated
+	APIToken string
+	// Address of the web dashboard listener, empty if disabled
+	DashboardAddr string
+	// Output format without UI : text or json
+	Output string
+	// Time series database exporters
+	Exporters []Exporter
+}
+
+// defaultApdexTarget is the Apdex target response time in ms used when a website does not define any
+const defaultApdexTarget = 500
+
+// defaultTimeframes are the statistics timeframes used when the configuration does not define any
+var defaultTimeframes = []string{"2m", "10m", "1h"}
+
+// urlList is a repeatable command line flag of URLs
+type urlList []string
+
+// String returns the string representation of a urlList
+func (l *urlList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a URL to a urlList
+func (l *urlList) Set(url string) error {
+	*l = append(*l, url)
+	return nil
 }
 
 // ParseFlags parse and returns the flags of the webmonitor cli command : Websites, Check interval, and timeout
-func ParseFlags() (int, []string, map[string]int, bool) {
+func ParseFlags() Config {
 	var uiEnabled bool
+	var urlFlags urlList
+	var interval, timeout int
+	var historyPath, metricsAddr, apiAddr, apiToken, dashboardAddr, output string
 	flag.BoolVar(&uiEnabled, "ui", true, "Display app with a ui")
+	flag.StringVar(&historyPath, "history", "", "Path of the file recording the history of the checks, overrides the JSON history path")
+	flag.Var(&urlFlags, "url", "URL to monitor, can be repeated. Use - to read URLs from the standard input")
+	flag.IntVar(&interval, "interval", 5, "Check interval in seconds of the URLs given with -url")
+	flag.IntVar(&timeout, "timeout", 5, "Timeout in seconds of the requests, overrides the JSON timeout")
+	flag.StringVar(&apiAddr, "api-addr", "", "Address such as :8080 on which the JSON API is served at /api")
+	flag.StringVar(&apiToken, "api-token", DefaultAPIToken(), "Bearer token required to add, pause, resume, check or remove websites through the API, which is read-only without it (default : $WEBMONITOR_API_TOKEN)")
+	flag.StringVar(&dashboardAddr, "dashboard-addr", "", "Address such as :8000 on which the web dashboard is served")
+	flag.StringVar(&output, "output", "text", "Output format without UI : text, or json for one JSON object per event")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "Address such as :9090 on which Prometheus metrics are served at /metrics")
 	flag.Parse()
-	if len(flag.Args()) < 1 {
-		fmt.Println("No JSON input file path specified")
-		return 0, make([]string, 0), make(map[string]int), false
+	if output != "text" && output != "json" {
+		fmt.Printf("Unknown output %q, it should be text or json\n", output)
+		return Config{}
+	}
+	input := JSONInput{Timeout: timeout}
+	if len(flag.Args()) >= 1 {
+		var err error
+		if input, err = readJSONInput(flag.Args()[0], parseJSONInput); err != nil {
+			fmt.Println(Redact(err.Error()))
+			return Config{}
+		}
+		// A timeout given on the command line prevails over the JSON one
+		if isFlagSet("timeout") {
+			input.Timeout = timeout
+		}
+	} else if len(urlFlags) == 0 {
+		fmt.Println("No JSON input file path or URL specified")
+		return Config{}
+	}
+	// Parsing the JSON
+	config := Config{Timeout: input.Timeout, Urls: make([]string, 0), Websites: make(map[string]Website), UIEnabled: uiEnabled, MetricsAddr: metricsAddr, APIAddr: apiAddr, APIToken: apiToken, DashboardAddr: dashboardAddr, Output: output}
+	for _, website := range mergeWebsites(input.Websites, urlFlags, interval, os.Stdin) {
+		if err := ParseWebsite(&website); err != nil {
+			fmt.Println(Redact(fmt.Sprintf("Website %s : %v", website.URL, err)))
+			return Config{}
+		}
+		config.Websites[website.URL] = website
+		config.Urls = append(config.Urls, website.URL)
+	}
+	// If timeout invalid
+	if input.Timeout <= 1 {
+		fmt.Println("Timeout should be an integer greater than 1")
+		return Config{}
+	}
+	// Parsing the timeframes
+	if len(input.Timeframes) == 0 {
+		input.Timeframes = defaultTimeframes
+	}
+	for _, timeframe := range input.Timeframes {
+		duration, err := ParseDuration(timeframe)
+		if err != nil || duration <= 0 {
+			fmt.Printf("Invalid timeframe %q, it should be a positive duration such as 5m, 1h or 30d\n", timeframe)
+			return Config{}
+		}
+		config.Timeframes = append(config.Timeframes, duration)
+	}
+	// Parsing the history settings
+	config.HistoryRetention = history.DefaultRetention
+	if input.History != nil {
+		config.HistoryPath = input.History.Path
+		for _, setting := range []struct {
+			value    string
+			duration *time.Duration
+		}{
+			{input.History.Raw, &config.HistoryRetention.Raw},
+			{input.History.Rollups, &config.HistoryRetention.Rollups},
+		} {
+			if setting.value == "" {
+				continue
+			}
+			duration, err := ParseDuration(setting.value)
+			if err != nil || duration <= 0 {
+				fmt.Printf("Invalid history retention %q, it should be a positive duration such as 12h or 30d\n", setting.value)
+				return Config{}
+			}
+			*setting.duration = duration
+		}
+	}
+	if historyPath != "" {
+		config.HistoryPath = historyPath
+	}
+	// Statistics are rebuilt from the raw records of the history, the rollups lacking the samples they keep
+	for _, timeframe := range config.Timeframes {
+		if config.HistoryPath != "" && timeframe > config.HistoryRetention.Raw {
+			fmt.Printf("Timeframe %s is longer than the %s raw retention of the history, which could not rebuild its statistics : shorten it or raise the raw retention\n",
+				FormatDuration(timeframe), FormatDuration(config.HistoryRetention.Raw))
+			return Config{}
+		}
+	}
+	// Parsing the exporters
+	for _, exporter := range input.Exporters {
+		if err := parseExporter(&exporter); err != nil {
+			fmt.Println(Redact(fmt.Sprintf("Exporter %s : %v", exporter.Type, err)))
+			return Config{}
+		}
+		config.Exporters = append(config.Exporters, exporter)
+	}
+
+	return config
+}
+
+// ParseWebsite validates a website configuration, applies its defaults and parses its settings
+func ParseWebsite(website *Website) error {
+	if website.Label == "" {
+		website.Label = website.URL
+	}
+	if website.Interval < 1 {
+		return fmt.Errorf("interval %d should be at least 1 second", website.Interval)
+	}
+	if website.ApdexTarget <= 0 {
+		website.ApdexTarget = defaultApdexTarget
+	}
+	if err := parseSLOs(website.SLOs); err != nil {
+		return err
+	}
+	if err := parseAnomaly(website.Anomaly); err != nil {
+		return err
+	}
+	return parseContent(website.Content)
+}
+
+// parseSLOs validates service level objectives, and parses their window
+func parseSLOs(slos []SLO) error {
+	for i := range slos {
+		slo := &slos[i]
+		if slo.Objective <= 0 || slo.Objective >= 100 {
+			return fmt.Errorf("SLO objective %v should be a percentage strictly between 0 and 100", slo.Objective)
+		}
+		if slo.Name == "" {
+			slo.Name = "availability"
+			if slo.Threshold > 0 {
+				slo.Name = fmt.Sprintf("latency %vms", slo.Threshold)
+			}
+		}
+		if slo.Window == "" {
+			slo.Window = "30d"
+		}
+		duration, err := ParseDuration(slo.Window)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("invalid SLO window %q, it should be a positive duration such as 7d or 30d", slo.Window)
+		}
+		slo.WindowDuration = duration
+	}
+	return nil
+}
+
+// parseAnomaly validates anomaly detection settings, if any, and applies their defaults
+func parseAnomaly(anomaly *Anomaly) error {
+	if anomaly == nil {
+		return nil
+	}
+	if anomaly.Sigmas == 0 {
+		anomaly.Sigmas = 3
+	}
+	if anomaly.Alpha == 0 {
+		anomaly.Alpha = 0.05
+	}
+	if anomaly.Sustain == "" {
+		anomaly.Sustain = "2m"
+	}
+	if anomaly.Sigmas < 0 {
+		return fmt.Errorf("anomaly sigmas %v should be positive", anomaly.Sigmas)
 	}
-	jsonpath := flag.Args()[0]
+	if anomaly.Alpha <= 0 || anomaly.Alpha >= 1 {
+		return fmt.Errorf("anomaly alpha %v should be strictly between 0 and 1", anomaly.Alpha)
+	}
+	duration, err := ParseDuration(anomaly.Sustain)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("invalid anomaly sustained period %q, it should be a duration such as 30s or 2m", anomaly.Sustain)
+	}
+	anomaly.SustainDuration = duration
+	return nil
+}
+
+// parseContent validates content change detection settings, if any, and compiles their ignore regular expression
+func parseContent(content *Content) error {
+	if content == nil {
+		return nil
+	}
+	if content.Keep == 0 {
+		content.Keep = 5
+	}
+	if content.Keep < 2 {
+		return fmt.Errorf("content keep %d should be at least 2, to diff the last versions", content.Keep)
+	}
+	content.Expected = strings.ToLower(content.Expected)
+	if _, err := hex.DecodeString(content.Expected); err != nil || (content.Expected != "" && len(content.Expected) != 64) {
+		return fmt.Errorf("content expected hash %q should be a SHA-256 in hexadecimal", content.Expected)
+	}
+	if content.Ignore != "" {
+		var err error
+		if content.IgnoreRegexp, err = regexp.Compile(content.Ignore); err != nil {
+			return fmt.Errorf("invalid content ignore regular expression : %v", err)
+		}
+	}
+	return nil
+}
+
+// parseExporter validates the settings of a time series database exporter, and applies their defaults
+func parseExporter(exporter *Exporter) error {
+	switch exporter.Type {
+	case "influxdb":
+		if (exporter.URL == "") == (exporter.Path == "") {
+			return fmt.Errorf("either a url or a path should be given")
+		}
+	case "statsd", "graphite":
+		if exporter.Address == "" {
+			return fmt.Errorf("an address should be given")
+		}
+	case "otlp":
+		if exporter.URL == "" {
+			return fmt.Errorf("a url should be given")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, it should be influxdb, statsd, graphite or otlp", exporter.Type)
+	}
+	if exporter.Traces && exporter.Type != "otlp" {
+		return fmt.Errorf("traces are only exported to otlp")
+	}
+	if exporter.Prefix == "" {
+		exporter.Prefix = "webmonitor"
+	}
+	if exporter.BatchSize == 0 {
+		exporter.BatchSize = 500
+	}
+	if exporter.BufferSize == 0 {
+		exporter.BufferSize = 10000
+	}
+	if exporter.FlushInterval == "" {
+		exporter.FlushInterval = "10s"
+	}
+	if exporter.BatchSize < 1 || exporter.BufferSize < exporter.BatchSize {
+		return fmt.Errorf("batch size %d should be at least 1, and at most the buffer size %d", exporter.BatchSize, exporter.BufferSize)
+	}
+	duration, err := ParseDuration(exporter.FlushInterval)
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid flush interval %q, it should be a positive duration such as 10s or 1m", exporter.FlushInterval)
+	}
+	exporter.FlushIntervalDuration = duration
+	return nil
+}
+
+// ParseDuration parses a duration such as "90s", "5m" or "1h30m", accepting a "d" suffix for days such as "30d",
+// and a "min" suffix for minutes such as "10min".
+func ParseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "min") {
+		s = strings.TrimSuffix(s, "in")
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// FormatDuration returns a short representation of a timeframe, such as 2min, 1h or 30d, which ParseDuration parses back.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dmin", d/time.Minute)
+	}
+	return d.String()
+}
+
+// isFlagSet returns whether a flag was explicitly set on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// mergeWebsites appends the URLs given on the command line, checked every interval, to the websites of the JSON configuration.
+// The URL - reads URLs from stdin. Websites with an interval lower than 1 are dropped, and only the first website of a URL is kept.
+func mergeWebsites(websites []Website, urlFlags []string, interval int, stdin io.Reader) []Website {
+	for _, url := range urlFlags {
+		urls := []string{url}
+		if url == "-" {
+			urls = readURLs(stdin)
+		}
+		for _, url := range urls {
+			websites = append(websites, Website{URL: url, Interval: interval})
+		}
+	}
+	merged := make([]Website, 0, len(websites))
+	seen := make(map[string]bool)
+	for _, website := range websites {
+		if website.Interval >= 1 && !seen[website.URL] {
+			seen[website.URL] = true
+			merged = append(merged, website)
+		}
+	}
+	return merged
+}
+
+// readURLs reads one URL per line, ignoring blank lines and # comments
+func readURLs(reader io.Reader) []string {
+	urls := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			urls = append(urls, line)
+		}
+	}
+	return urls
+}
+
+// readJSONInput reads and decodes a JSON configuration file, with parse
+func readJSONInput(jsonpath string, parse func([]byte) (JSONInput, error)) (JSONInput, error) {
 	// Open the file
 	jsonFile, err := os.Open(jsonpath)
 	if err != nil {
-		// Handle error
-		fmt.Println(err)
-		return 0, make([]string, 0), make(map[string]int), false
+		return JSONInput{}, err
 	}
 	defer jsonFile.Close()
 	// Read the Json
-	var input JSONInput
 	byteValue, _ := ioutil.ReadAll(jsonFile)
-	json.Unmarshal(byteValue, &input)
+	return parse(byteValue)
+}
 
-	// Parsing the JSON
-	intervals := make(map[string]int)
-	urls := make([]string, 0)
-	for _, website := range input.Websites {
-		// Interval should be greater than 1, no duplicate URL
-		if website.Interval >= 1 && intervals[website.URL] == 0 {
-			intervals[website.URL] = website.Interval
-			urls = append(urls, website.URL)
+// parseJSONInput decodes a JSON configuration, resolving the ${ENV_VAR} and ${file:/path} references of its strings.
+// The URLs of the websites are kept as written as well, and those of the status page are not resolved : they are the keys of the history.
+func parseJSONInput(byteValue []byte) (JSONInput, error) {
+	var input, templates JSONInput
+	if err := json.Unmarshal(byteValue, &templates); err != nil {
+		return input, err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(byteValue, &raw); err != nil {
+		return input, err
+	}
+	raw, err := interpolateValue(raw)
+	if err != nil {
+		return input, err
+	}
+	// Decoding the interpolated configuration
+	byteValue, _ = json.Marshal(raw)
+	if err := json.Unmarshal(byteValue, &input); err != nil {
+		return input, err
+	}
+	for i := range input.Websites {
+		template := templates.Websites[i]
+		input.Websites[i].Template = template.URL
+		input.Websites[i].Label = label(template.URL)
+		// Resolving the references of the website again, to redact its own values
+		references := []string{template.URL}
+		for _, header := range template.Headers {
+			references = append(references, header)
+		}
+		for _, reference := range references {
+			_, values, _ := interpolate(reference)
+			for _, value := range values {
+				input.Websites[i].values = addValue(input.Websites[i].values, value)
+			}
 		}
 	}
-	// If timeout invalid
-	if input.Timeout <= 1 {
-		fmt.Println("Timeout specified in JSON should be an integer greater than 1")
-		return 0, make([]string, 0), make(map[string]int), false
+	if input.StatusPage != nil {
+		for i, group := range input.StatusPage.Groups {
+			for j := range group.Websites {
+				group.Websites[j].URL = templates.StatusPage.Groups[i].Websites[j].URL
+			}
+		}
 	}
+	return input, nil
+}
 
-	return input.Timeout, urls, intervals, uiEnabled
+// parseHistoryInput decodes a JSON configuration for the commands reading the history, which only resolves the references
+// of what they read : the history path, and the titles and names of the status page. The URLs of the websites are resolved
+// when their references are defined, so that they can be given resolved, and kept as written otherwise.
+func parseHistoryInput(byteValue []byte) (JSONInput, error) {
+	var input JSONInput
+	if err := json.Unmarshal(byteValue, &input); err != nil {
+		return input, err
+	}
+	if input.History != nil {
+		if err := resolve(&input.History.Path); err != nil {
+			return input, err
+		}
+	}
+	for i := range input.Websites {
+		input.Websites[i].Template = input.Websites[i].URL
+		resolve(&input.Websites[i].URL)
+	}
+	if input.StatusPage != nil {
+		texts := []*string{&input.StatusPage.Title, &input.StatusPage.URL}
+		for i, group := range input.StatusPage.Groups {
+			texts = append(texts, &input.StatusPage.Groups[i].Name)
+			for j := range group.Websites {
+				texts = append(texts, &group.Websites[j].Name)
+			}
+		}
+		for _, text := range texts {
+			if err := resolve(text); err != nil {
+				return input, err
+			}
+		}
+	}
+	return input, nil
 }
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadURLs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", []string{}},
+		{"https://example.com\nhttps://example.org\n", []string{"https://example.com", "https://example.org"}},
+		// Blank lines, surrounding spaces and comments
+		{"\n  https://example.com  \n\n# staging\n\t\nhttps://example.org", []string{"https://example.com", "https://example.org"}},
+		// Windows line endings
+		{"https://example.com\r\nhttps://example.org\r\n", []string{"https://example.com", "https://example.org"}},
+		// Duplicates are kept, mergeWebsites drops them
+		{"https://example.com\nhttps://example.com\n", []string{"https://example.com", "https://example.com"}},
+	}
+	for _, test := range tests {
+		if got := readURLs(strings.NewReader(test.input)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("readURLs(%q) == %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestMergeWebsites(t *testing.T) {
+	configured := Website{URL: "https://example.com", Interval: 10, Headers: map[string]string{"Accept": "text/html"}}
+	tests := []struct {
+		name     string
+		websites []Website
+		urlFlags []string
+		stdin    string
+		want     []Website
+	}{
+		{"flags only", nil, []string{"https://example.com", "https://example.org"}, "",
+			[]Website{{URL: "https://example.com", Interval: 5}, {URL: "https://example.org", Interval: 5}}},
+		{"flags after the JSON websites", []Website{configured}, []string{"https://example.org"}, "",
+			[]Website{configured, {URL: "https://example.org", Interval: 5}}},
+		{"duplicate flag of a JSON website", []Website{configured}, []string{"https://example.com"}, "",
+			[]Website{configured}},
+		{"duplicate flags", nil, []string{"https://example.com", "https://example.com"}, "",
+			[]Website{{URL: "https://example.com", Interval: 5}}},
+		{"stdin in place of -", nil, []string{"https://example.net", "-", "https://example.org"}, "https://example.com\n\n# comment\nhttps://example.org\n",
+			[]Website{{URL: "https://example.net", Interval: 5}, {URL: "https://example.com", Interval: 5}, {URL: "https://example.org", Interval: 5}}},
+		{"stdin read once", nil, []string{"-", "-"}, "https://example.com\n",
+			[]Website{{URL: "https://example.com", Interval: 5}}},
+		{"empty stdin", []Website{configured}, []string{"-"}, "\n\n",
+			[]Website{configured}},
+		{"invalid interval", []Website{{URL: "https://example.com"}, {URL: "https://example.com", Interval: 2}}, nil, "",
+			[]Website{{URL: "https://example.com", Interval: 2}}},
+	}
+	for _, test := range tests {
+		got := mergeWebsites(test.websites, test.urlFlags, 5, strings.NewReader(test.stdin))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s : mergeWebsites == %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"90s", 90 * time.Second},
+		{"5m", 5 * time.Minute},
+		{"10min", 10 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"30d", 30 * 24 * time.Hour},
+	}
+	for _, test := range tests {
+		if got, err := ParseDuration(test.text); err != nil || got != test.want {
+			t.Errorf("ParseDuration(%q) == %v, %v, want %v", test.text, got, err, test.want)
+		}
+	}
+	for _, text := range []string{"", "5", "d", "1.5d", "tenmin"} {
+		if got, err := ParseDuration(text); err == nil {
+			t.Errorf("ParseDuration(%q) == %v, want an error", text, got)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{30 * time.Second, "30s"},
+		{90 * time.Second, "1m30s"},
+		{2 * time.Minute, "2min"},
+		{90 * time.Minute, "90min"},
+		{time.Hour, "1h"},
+		{24 * time.Hour, "24h"},
+		{30 * 24 * time.Hour, "30d"},
+	}
+	for _, test := range tests {
+		got := FormatDuration(test.duration)
+		if got != test.want {
+			t.Errorf("FormatDuration(%v) == %q, want %q", test.duration, got, test.want)
+		}
+		// Formatted durations are parsed back
+		if parsed, err := ParseDuration(got); err != nil || parsed != test.duration {
+			t.Errorf("ParseDuration(%q) == %v, %v, want %v", got, parsed, err, test.duration)
+		}
+	}
+}
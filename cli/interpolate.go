@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholder matches ${ENV_VAR} and ${file:/path/to/secret} references, as well as the $$ escape sequence
+var placeholder = regexp.MustCompile(`\$\$|\$\{([^}]*)\}`)
+
+// secrets contains the values resolved through interpolation, so that they can be redacted before being printed
+var secrets []string
+
+// Redact replaces every value resolved through interpolation found in s by a placeholder, the longest ones first.
+// Websites are rather shown by their Label, and their failure reasons redacted by their Redact method.
+func Redact(s string) string {
+	return redactValues(s, secrets)
+}
+
+// redactValues replaces every value found in s by a placeholder, values being sorted from the longest to the shortest
+func redactValues(s string, values []string) string {
+	for _, value := range values {
+		s = strings.Replace(s, value, "****", -1)
+	}
+	return s
+}
+
+// addValue adds a resolved value to values, sorted from the longest to the shortest so that no value is redacted within another one.
+// Empty values are ignored.
+func addValue(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	values = append(values, value)
+	sort.SliceStable(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// interpolate resolves ${ENV_VAR} and ${file:/path} references within a string. $$ is an escaped $.
+// Every resolved value is returned, to be redacted whatever its length.
+func interpolate(s string) (string, []string, error) {
+	var err error
+	var values []string
+	result := placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$$" || err != nil {
+			return "$"
+		}
+		reference := match[2 : len(match)-1]
+		var value string
+		if strings.HasPrefix(reference, "file:") {
+			// Reading a secret file, ignoring the trailing new line
+			content, readErr := ioutil.ReadFile(strings.TrimPrefix(reference, "file:"))
+			if readErr != nil {
+				err = fmt.Errorf("cannot resolve ${%s}: %v", reference, readErr)
+				return ""
+			}
+			value = strings.TrimRight(string(content), "\r\n")
+		} else {
+			var defined bool
+			value, defined = os.LookupEnv(reference)
+			if !defined {
+				err = fmt.Errorf("undefined environment variable ${%s}", reference)
+				return ""
+			}
+		}
+		values = addValue(values, value)
+		return value
+	})
+	return result, values, err
+}
+
+// resolve resolves the references of a string of the configuration in place, unless they are undefined.
+// The resolved values are redacted by Redact.
+func resolve(s *string) error {
+	value, values, err := interpolate(*s)
+	for _, resolved := range values {
+		secrets = addValue(secrets, resolved)
+	}
+	if err == nil {
+		*s = value
+	}
+	return err
+}
+
+// label returns a string as written in the configuration, its references replaced by a placeholder and its $$ by $
+func label(s string) string {
+	return placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		return "****"
+	})
+}
+
+// interpolateValue walks through a decoded JSON value and interpolates every string it contains
+func interpolateValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		err := resolve(&v)
+		return v, err
+	case []interface{}:
+		for i, element := range v {
+			interpolated, err := interpolateValue(element)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = interpolated
+		}
+	case map[string]interface{}:
+		for key, element := range v {
+			interpolated, err := interpolateValue(element)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = interpolated
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseJSONInputInterpolation(t *testing.T) {
+	// Test if environment variables and secret files are resolved, and redacted whatever their length
+	os.Setenv("WEBMONITOR_TEST_HOST", "example.com")
+	defer os.Unsetenv("WEBMONITOR_TEST_HOST")
+	os.Setenv("WEBMONITOR_TEST_KEY", "k3y-1234")
+	defer os.Unsetenv("WEBMONITOR_TEST_KEY")
+	os.Setenv("WEBMONITOR_TEST_SHORT", "ab")
+	defer os.Unsetenv("WEBMONITOR_TEST_SHORT")
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	defer os.RemoveAll(dir)
+	secretPath := filepath.Join(dir, "token")
+	ioutil.WriteFile(secretPath, []byte("s3cr3t\n"), 0600)
+
+	input, err := parseJSONInput([]byte(`{"timeout": 5, "websites": [{
+		"url": "https://${WEBMONITOR_TEST_HOST}/health?price=$$5&key=${WEBMONITOR_TEST_KEY}&tenant=${WEBMONITOR_TEST_SHORT}",
+		"interval": 2,
+		"headers": {"Authorization": "Bearer ${file:` + secretPath + `}"}
+	}], "statusPage": {"groups": [{"websites": [{"url": "https://${WEBMONITOR_TEST_HOST}/health"}]}]}}`))
+	if err != nil {
+		t.Fatalf("parseJSONInput returned %v", err)
+	}
+	website := input.Websites[0]
+	if want := "https://example.com/health?price=$5&key=k3y-1234&tenant=ab"; website.URL != want {
+		t.Errorf("URL == %q, want %q", website.URL, want)
+	}
+	if website.Headers["Authorization"] != "Bearer s3cr3t" {
+		t.Errorf("Authorization header == %q, want %q", website.Headers["Authorization"], "Bearer s3cr3t")
+	}
+	// The website is shown with its references redacted, even the short ones
+	if want := "https://****/health?price=$5&key=****&tenant=****"; website.Label != want {
+		t.Errorf("Label == %q, want %q", website.Label, want)
+	}
+	reason := `Get "https://example.com/health?price=$5&key=k3y-1234&tenant=ab": token s3cr3t refused, tenant ab`
+	if want := `Get "https://****/health?price=$5&key=****&tenant=****": token **** refused, tenant ****`; website.Redact(reason) != want {
+		t.Errorf("Redact(%q) == %q, want %q", reason, website.Redact(reason), want)
+	}
+	if got := Redact("token=s3cr3t, tenant=ab"); got != "token=****, tenant=****" {
+		t.Errorf("Redact(%q) == %q, want %q", "token=s3cr3t, tenant=ab", got, "token=****, tenant=****")
+	}
+	// The history keys are the URLs as written
+	if want := "https://${WEBMONITOR_TEST_HOST}/health?price=$$5&key=${WEBMONITOR_TEST_KEY}&tenant=${WEBMONITOR_TEST_SHORT}"; website.HistoryKey() != want {
+		t.Errorf("HistoryKey() == %q, want %q", website.HistoryKey(), want)
+	}
+	if url := input.StatusPage.Groups[0].Websites[0].URL; url != "https://${WEBMONITOR_TEST_HOST}/health" {
+		t.Errorf("status page URL == %q, want it as written", url)
+	}
+	if key := historyKey(input, website.URL); key != website.HistoryKey() {
+		t.Errorf("historyKey(%q) == %q, want %q", website.URL, key, website.HistoryKey())
+	}
+	if key := historyKey(input, "https://example.org"); key != "https://example.org" {
+		t.Errorf("historyKey(%q) == %q, want the URL", "https://example.org", key)
+	}
+}
+
+func TestParseWebsiteLabel(t *testing.T) {
+	// Test if websites given otherwise than in the JSON configuration are shown by their URL
+	website := Website{URL: "https://example.com/?token=abc", Interval: 5}
+	if err := ParseWebsite(&website); err != nil || website.Label != website.URL {
+		t.Errorf("Label == %q, %v, want %q", website.Label, err, website.URL)
+	}
+	if reason := "timeout"; website.Redact(reason) != reason {
+		t.Errorf("Redact(%q) == %q, want it unchanged", reason, website.Redact(reason))
+	}
+}
+
+func TestParseHistoryInput(t *testing.T) {
+	// Test if the commands reading the history only resolve what they read, and need no secret of the monitored websites
+	os.Setenv("WEBMONITOR_TEST_DIR", "/var/lib/webmonitor")
+	defer os.Unsetenv("WEBMONITOR_TEST_DIR")
+	os.Setenv("WEBMONITOR_TEST_HOST", "example.com")
+	defer os.Unsetenv("WEBMONITOR_TEST_HOST")
+	os.Unsetenv("WEBMONITOR_TEST_UNDEFINED")
+	input, err := parseHistoryInput([]byte(`{"timeout": 5, "history": {"path": "${WEBMONITOR_TEST_DIR}/history.db"}, "websites": [
+		{"url": "https://${WEBMONITOR_TEST_HOST}/health?key=${WEBMONITOR_TEST_UNDEFINED}", "interval": 2, "apdexTarget": 300, "headers": {"Authorization": "${file:/undefined}"}},
+		{"url": "https://${WEBMONITOR_TEST_HOST}/", "interval": 2}
+	], "exporters": [{"type": "influxdb", "url": "${WEBMONITOR_TEST_UNDEFINED}"}],
+	"statusPage": {"title": "${WEBMONITOR_TEST_HOST} status", "groups": [{"websites": [{"url": "https://${WEBMONITOR_TEST_HOST}/"}]}]}}`))
+	if err != nil {
+		t.Fatalf("parseHistoryInput returned %v", err)
+	}
+	if input.History.Path != "/var/lib/webmonitor/history.db" {
+		t.Errorf("history path == %q, want it resolved", input.History.Path)
+	}
+	if input.StatusPage.Title != "example.com status" || input.StatusPage.Groups[0].Websites[0].URL != "https://${WEBMONITOR_TEST_HOST}/" {
+		t.Errorf("status page == %+v, want its title resolved and its URLs as written", input.StatusPage)
+	}
+	// Websites are found by their URL as written, or resolved when it can be
+	if key := historyKey(input, "https://${WEBMONITOR_TEST_HOST}/health?key=${WEBMONITOR_TEST_UNDEFINED}"); key != input.Websites[0].Template || input.Websites[0].ApdexTarget != 300 {
+		t.Errorf("historyKey == %q, want %q", key, input.Websites[0].Template)
+	}
+	if key := historyKey(input, "https://example.com/"); key != "https://${WEBMONITOR_TEST_HOST}/" {
+		t.Errorf("historyKey(%q) == %q, want %q", "https://example.com/", key, "https://${WEBMONITOR_TEST_HOST}/")
+	}
+	// The history path is needed
+	if _, err := parseHistoryInput([]byte(`{"history": {"path": "${WEBMONITOR_TEST_UNDEFINED}"}}`)); err == nil {
+		t.Errorf("parseHistoryInput with an undefined history path should return an error")
+	}
+}
+
+func TestParseJSONInputInvalid(t *testing.T) {
+	// Test if a configuration of the wrong type is reported
+	if _, err := parseJSONInput([]byte(`{"timeout": "5", "websites": []}`)); err == nil {
+		t.Errorf("parseJSONInput with a string timeout should return an error")
+	}
+}
+
+func TestParseJSONInputUndefinedVariable(t *testing.T) {
+	// Test if an undefined variable is reported
+	os.Unsetenv("WEBMONITOR_TEST_UNDEFINED")
+	_, err := parseJSONInput([]byte(`{"timeout": 5, "websites": [{"url": "https://${WEBMONITOR_TEST_UNDEFINED}", "interval": 2}]}`))
+	if err == nil {
+		t.Errorf("parseJSONInput with an undefined variable should return an error")
+	}
+}
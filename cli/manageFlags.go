@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ManageCommands are the webmonitor commands managing a running instance through its API
+var ManageCommands = map[string]bool{"list": true, "add": true, "pause": true, "resume": true, "check": true, "remove": true}
+
+// ManageConfig is the parsed configuration of a webmonitor command managing a running instance
+type ManageConfig struct {
+	// list, add, pause, resume, check or remove
+	Command string
+	// Address of the API of the running instance
+	APIAddr  string
+	APIToken string
+	// Website to add
+	Website Website
+	// Id or URL of the managed website
+	Target string
+}
+
+// tagList is a repeatable command line flag of name=value tags
+type tagList map[string]string
+
+// String returns the string representation of a tagList
+func (l tagList) String() string {
+	tags := make([]string, 0, len(l))
+	for name, value := range l {
+		tags = append(tags, name+"="+value)
+	}
+	return strings.Join(tags, ",")
+}
+
+// Set adds a name=value tag to a tagList
+func (l tagList) Set(tag string) error {
+	parts := strings.SplitN(tag, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("tag %q should be written as name=value", tag)
+	}
+	l[parts[0]] = parts[1]
+	return nil
+}
+
+// DefaultAPIToken returns the API token read from the WEBMONITOR_API_TOKEN environment variable, if any
+func DefaultAPIToken() string {
+	return os.Getenv("WEBMONITOR_API_TOKEN")
+}
+
+// ParseManageFlags parse and returns the flags of a webmonitor command managing a running instance. The boolean is false if they are invalid.
+func ParseManageFlags(command string, args []string) (ManageConfig, bool) {
+	config := ManageConfig{Command: command}
+	tags := make(tagList)
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	flags.StringVar(&config.APIAddr, "api-addr", "localhost:8080", "Address of the API of the running webmonitor")
+	flags.StringVar(&config.APIToken, "api-token", DefaultAPIToken(), "Token of the API of the running webmonitor (default : $WEBMONITOR_API_TOKEN)")
+	if command == "add" {
+		flags.IntVar(&config.Website.Interval, "interval", 5, "Check interval in seconds")
+		flags.IntVar(&config.Website.ApdexTarget, "apdex-target", defaultApdexTarget, "Apdex target response time in ms")
+		flags.Var(tags, "tag", "Tag of the website as name=value, can be repeated")
+	}
+	flags.Parse(args)
+	if command == "list" {
+		return config, true
+	}
+	if flags.NArg() != 1 {
+		if command == "add" {
+			fmt.Println("Usage : webmonitor add [options] URL")
+		} else {
+			fmt.Printf("Usage : webmonitor %s [options] ID|URL\n", command)
+		}
+		return config, false
+	}
+	config.Target = flags.Arg(0)
+	if command == "add" {
+		config.Website.URL = config.Target
+		if len(tags) > 0 {
+			config.Website.Tags = tags
+		}
+	}
+	return config, true
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// ReportConfig is the parsed configuration of the webmonitor report command
+type ReportConfig struct {
+	HistoryPath string
+	// Reported period
+	From time.Time
+	To   time.Time
+	// Output format : table, csv, json or markdown
+	Format string
+	// Reported URLs, every URL of the history if empty
+	Urls []string
+	// Apdex target response time in ms of each URL, read from the JSON configuration
+	ApdexTargets map[string]int
+	// Apdex target response time in ms of the other URLs
+	ApdexTarget int
+}
+
+// reportFormats are the output formats of the report command
+var reportFormats = map[string]bool{"table": true, "csv": true, "json": true, "markdown": true}
+
+// ParseReportFlags parse and returns the flags of the webmonitor report command. The boolean is false if they are invalid.
+func ParseReportFlags(args []string) (ReportConfig, bool) {
+	var config ReportConfig
+	var from, to string
+	var urlFlags urlList
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	flags.StringVar(&config.HistoryPath, "history", "", "Path of the history file, overrides the JSON history path")
+	flags.StringVar(&from, "from", "", "Start of the period, as 2006-01-02 or RFC 3339 (default : 30 days before -to)")
+	flags.StringVar(&to, "to", "", "End of the period, as 2006-01-02 or RFC 3339 (default : now)")
+	flags.StringVar(&config.Format, "format", "table", "Output format : table, csv, json or markdown")
+	flags.Var(&urlFlags, "url", "URL to report on, can be repeated (default : every URL of the history)")
+	flags.IntVar(&config.ApdexTarget, "apdex-target", defaultApdexTarget, "Apdex target response time in ms of the websites missing from the JSON configuration")
+	flags.Parse(args)
+	config.Urls = urlFlags
+	var input JSONInput
+	var ok bool
+	if config.HistoryPath, input, ok = readConfigArgs(config.HistoryPath, flags.Args()); !ok {
+		return config, false
+	}
+	// The history records the websites by their key
+	for i, url := range config.Urls {
+		config.Urls[i] = historyKey(input, url)
+	}
+	config.ApdexTargets = make(map[string]int)
+	for _, website := range input.Websites {
+		if website.ApdexTarget > 0 {
+			config.ApdexTargets[website.HistoryKey()] = website.ApdexTarget
+		}
+	}
+	if !reportFormats[config.Format] {
+		fmt.Printf("Unknown format %q, it should be table, csv, json or markdown\n", config.Format)
+		return config, false
+	}
+	// Parsing the period
+	var err error
+	config.To = time.Now()
+	if to != "" {
+		if config.To, err = parseDate(to); err != nil {
+			fmt.Println(err)
+			return config, false
+		}
+	}
+	config.From = config.To.Add(-30 * 24 * time.Hour)
+	if from != "" {
+		if config.From, err = parseDate(from); err != nil {
+			fmt.Println(err)
+			return config, false
+		}
+	}
+	if !config.From.Before(config.To) {
+		fmt.Println("The start of the period should be before its end")
+		return config, false
+	}
+	return config, true
+}
+
+// readConfigArgs reads the JSON configuration given as argument, if any, and returns the history path given with a flag or read from it.
+// The secrets of the monitored websites are not needed to read the configuration.
+func readConfigArgs(historyPath string, args []string) (string, JSONInput, bool) {
+	var input JSONInput
+	if len(args) >= 1 {
+		var err error
+		if input, err = readJSONInput(args[0], parseHistoryInput); err != nil {
+			fmt.Println(Redact(err.Error()))
+			return "", input, false
+		}
+		if historyPath == "" && input.History != nil {
+			historyPath = input.History.Path
+		}
+	}
+	if historyPath == "" {
+		fmt.Println("No history file specified, use -history or a JSON configuration with a history path")
+		return "", input, false
+	}
+	return historyPath, input, true
+}
+
+// parseDate parses a local date such as 2006-01-02, or a RFC 3339 time
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q, it should be formatted as 2006-01-02 or 2006-01-02T15:04:05Z07:00", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+
+	"github.com/hugo-sv/webmonitor/statuspage"
+)
+
+// StatusConfig is the parsed configuration of the webmonitor status-page command
+type StatusConfig struct {
+	HistoryPath string
+	// Directory the status page is written to, if it is not served
+	OutDir string
+	// Address on which the status page is served, empty to write it once
+	Addr string
+	// Number of days of the uptime bars
+	Days int
+	// Status page, whose URLs are the keys of the history
+	Page statuspage.Config
+}
+
+// schemePrefix matches the scheme and www prefix of a URL
+var schemePrefix = regexp.MustCompile("^(https?://)?(www\\.)?")
+
+// ParseStatusFlags parse and returns the flags of the webmonitor status-page command. The boolean is false if they are invalid.
+func ParseStatusFlags(args []string) (StatusConfig, bool) {
+	var config StatusConfig
+	flags := flag.NewFlagSet("status-page", flag.ExitOnError)
+	flags.StringVar(&config.HistoryPath, "history", "", "Path of the history file, overrides the JSON history path")
+	flags.StringVar(&config.OutDir, "out", "status", "Directory the status page and its feeds are written to")
+	flags.StringVar(&config.Addr, "addr", "", "Address such as :8000 on which the status page is served, rather than written")
+	flags.IntVar(&config.Days, "days", 90, "Number of days of the uptime bars")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Println("Usage : webmonitor status-page [options] JSON_PATH")
+		return config, false
+	}
+	var input JSONInput
+	var ok bool
+	if config.HistoryPath, input, ok = readConfigArgs(config.HistoryPath, flags.Args()); !ok {
+		return config, false
+	}
+	if input.StatusPage == nil || len(input.StatusPage.Groups) == 0 {
+		fmt.Println("No status page groups specified, the JSON configuration should have a statusPage section")
+		return config, false
+	}
+	if config.Days < 1 {
+		fmt.Printf("Invalid number of days %d, it should be at least 1\n", config.Days)
+		return config, false
+	}
+	config.Page = statuspage.Config{Title: input.StatusPage.Title, URL: input.StatusPage.URL}
+	if config.Page.Title == "" {
+		config.Page.Title = "Status"
+	}
+	for _, group := range input.StatusPage.Groups {
+		parsed := statuspage.GroupConfig{Name: group.Name}
+		for _, website := range group.Websites {
+			if website.URL == "" {
+				fmt.Printf("Status page group %q : every website should have a url\n", group.Name)
+				return config, false
+			}
+			// The URLs are the keys of the history, written as in the websites section, and the page never shows them unless they have no name
+			if website.Name == "" {
+				website.Name = schemePrefix.ReplaceAllString(website.URL, "")
+			}
+			parsed.Websites = append(parsed.Websites, website)
+		}
+		config.Page.Groups = append(config.Page.Groups, parsed)
+	}
+	return config, true
+}
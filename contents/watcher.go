@@ -0,0 +1,110 @@
+package contents
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Version is a distinct content of a website, with the time it was first seen
+type Version struct {
+	Time time.Time
+	// SHA-256 of the body, in hexadecimal, once its volatile regions are stripped
+	Hash string
+	Body []byte
+}
+
+// Change is a change of the content of a website
+type Change struct {
+	Previous Version
+	Current  Version
+	// Whether the current content is the expected one, always false without an expected hash
+	Expected bool
+}
+
+// Watcher hashes the bodies of a website to detect content changes, and keeps its latest versions.
+// Bodies are hashed as read by the checks, the first MiB of longer ones : a change past it is not detected.
+type Watcher struct {
+	mutex sync.Mutex
+	// Volatile regions removed before hashing, such as timestamps or tokens, nil to hash the whole body
+	ignore *regexp.Regexp
+	// Pinned hash of the content, empty to compare each content with the previous one
+	expected string
+	// Number of versions kept
+	keep     int
+	versions []Version
+}
+
+// NewWatcher returns a new Watcher, keeping the last keep versions of the content
+func NewWatcher(ignore *regexp.Regexp, expected string, keep int) *Watcher {
+	return &Watcher{ignore: ignore, expected: expected, keep: keep}
+}
+
+// Hash returns the hash of a body, once its volatile regions are stripped
+func (w *Watcher) Hash(body []byte) string {
+	if w.ignore != nil {
+		body = w.ignore.ReplaceAll(body, nil)
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Check adds a body of the website fetched at a given time. It returns the change of content, and whether the change should be alerted on :
+// whenever the content differs from the previous one, but for a first content matching the expected hash, if any.
+// With an expected hash, each change is alerted on while the content differs from it, so that a content changing again is seen.
+func (w *Watcher) Check(t time.Time, body []byte) (Change, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	current := Version{Time: t, Hash: w.Hash(body), Body: body}
+	var previous Version
+	if len(w.versions) > 0 {
+		previous = w.versions[len(w.versions)-1]
+	}
+	if previous.Hash == current.Hash {
+		return Change{}, false
+	}
+	w.versions = append(w.versions, current)
+	if len(w.versions) > w.keep {
+		w.versions = append(w.versions[:0], w.versions[len(w.versions)-w.keep:]...)
+	}
+	change := Change{Previous: previous, Current: current, Expected: w.expected != "" && current.Hash == w.expected}
+	if w.expected == "" {
+		// The first content is the reference
+		return change, previous.Hash != ""
+	}
+	// The first content is only alerted on if it is not the expected one
+	return change, previous.Hash != "" || !change.Expected
+}
+
+// Versions returns the versions of the content, from the oldest to the most recent
+func (w *Watcher) Versions() []Version {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]Version(nil), w.versions...)
+}
+
+// Diff returns the lines of two contents, from their first differing line to their last, prefixed by - when removed and + when added
+func Diff(previous []byte, current []byte) []string {
+	before := bytes.Split(previous, []byte("\n"))
+	after := bytes.Split(current, []byte("\n"))
+	// Skipping the common lines at the start and at the end
+	start := 0
+	for start < len(before) && start < len(after) && bytes.Equal(before[start], after[start]) {
+		start++
+	}
+	end := 0
+	for end < len(before)-start && end < len(after)-start && bytes.Equal(before[len(before)-1-end], after[len(after)-1-end]) {
+		end++
+	}
+	lines := make([]string, 0)
+	for _, line := range before[start : len(before)-end] {
+		lines = append(lines, "- "+string(line))
+	}
+	for _, line := range after[start : len(after)-end] {
+		lines = append(lines, "+ "+string(line))
+	}
+	return lines
+}
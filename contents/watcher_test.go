@@ -0,0 +1,68 @@
+package contents
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := NewWatcher(regexp.MustCompile(`<time>[^<]*</time>`), "", 2)
+	cases := []struct {
+		body    string
+		changed bool
+	}{
+		// The first content is the reference
+		{"<h1>Hello</h1><time>10:00</time>", false},
+		// Volatile regions are ignored
+		{"<h1>Hello</h1><time>10:01</time>", false},
+		{"<h1>Hacked</h1><time>10:02</time>", true},
+		{"<h1>Hello</h1><time>10:03</time>", true},
+	}
+	for i, c := range cases {
+		if _, changed := w.Check(start.Add(time.Duration(i)*time.Minute), []byte(c.body)); changed != c.changed {
+			t.Errorf("Check(%q) changed == %v, want %v", c.body, changed, c.changed)
+		}
+	}
+	versions := w.Versions()
+	if len(versions) != 2 || string(versions[0].Body) != cases[2].body || string(versions[1].Body) != cases[3].body {
+		t.Errorf("Versions() == %v, want the last 2 versions", versions)
+	}
+
+	// With a pinned hash, every change is alerted on while the content differs from the expected one
+	expected := w.Hash([]byte("<h1>Hello</h1>"))
+	w = NewWatcher(regexp.MustCompile(`<time>[^<]*</time>`), expected, 5)
+	cases = []struct {
+		body    string
+		changed bool
+	}{
+		{"<h1>Hello</h1><time>10:00</time>", false},
+		{"<h1>Hacked</h1>", true},
+		{"<h1>Hacked again</h1>", true},
+		{"<h1>Hacked again</h1><time>10:03</time>", false},
+		{"<h1>Hello</h1>", true},
+	}
+	for i, c := range cases {
+		if _, changed := w.Check(start.Add(time.Duration(i)*time.Minute), []byte(c.body)); changed != c.changed {
+			t.Errorf("Check(%q) with a pinned hash changed == %v, want %v", c.body, changed, c.changed)
+		}
+	}
+	// An unexpected first content is alerted on
+	w = NewWatcher(nil, expected, 5)
+	if change, changed := w.Check(start, []byte("<h1>Hacked</h1>")); !changed || change.Expected {
+		t.Errorf("Check() of an unexpected first content == %v, %v, want an unexpected change", change, changed)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := Diff([]byte("a\nb\nc\nd"), []byte("a\nx\ny\nd"))
+	want := []string{"- b", "- c", "+ x", "+ y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() == %v, want %v", got, want)
+	}
+	if got := Diff([]byte("a\nb"), []byte("a\nb")); len(got) != 0 {
+		t.Errorf("Diff() of equal contents == %v, want nothing", got)
+	}
+}
@@ -0,0 +1,299 @@
+package dashboard
+
+// indexHTML is the page of the dashboard. It mirrors the UI : the statistics of the websites over the selected timeframe,
+// the details and recent response times of the selected website, and the alerts. It is updated by the Server-Sent Events of /events.
+const indexHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Webmonitor</title>
+<style>
+	body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; background: #101418; color: #e6e6e6; }
+	header { display: flex; align-items: center; justify-content: space-between; padding: 12px 20px; border-bottom: 1px solid #2a9fd6; }
+	h1 { margin: 0; font-size: 20px; color: #f0c040; }
+	h2 { margin: 0 0 10px; font-size: 15px; color: #2a9fd6; font-weight: normal; }
+	main { display: grid; grid-template-columns: minmax(0, 3fr) minmax(0, 2fr); gap: 16px; padding: 16px 20px; }
+	section { border: 1px solid #2a9fd6; border-radius: 4px; padding: 12px; overflow-x: auto; }
+	#alerts-panel { grid-row: span 2; }
+	table { width: 100%; border-collapse: collapse; font-variant-numeric: tabular-nums; }
+	th, td { padding: 4px 8px; text-align: center; white-space: nowrap; }
+	th { color: #f0c040; font-weight: normal; border-bottom: 1px solid #333; }
+	td.url { text-align: left; max-width: 320px; overflow: hidden; text-overflow: ellipsis; }
+	#summary tbody tr { cursor: pointer; }
+	#summary tbody tr:hover { background: #1b232b; }
+	#summary tbody tr.active { background: #203040; }
+	.down { color: #e05050; }
+	.up { color: #50c050; }
+	.hint, .empty { color: #888; font-size: 13px; }
+	button { background: none; color: #e6e6e6; border: 1px solid #555; border-radius: 3px; padding: 3px 10px; cursor: pointer; }
+	button.active { border-color: #f0c040; color: #f0c040; }
+	#status { font-size: 13px; }
+	#chart { width: 100%; height: 160px; display: block; margin-top: 12px; }
+	#alerts { list-style: none; margin: 0; padding: 0; font-size: 14px; }
+	#alerts li { padding: 6px 0; border-bottom: 1px solid #222; }
+	#alerts time { color: #888; margin-right: 8px; }
+	@media (max-width: 900px) { main { grid-template-columns: 1fr; } #alerts-panel { grid-row: auto; } }
+</style>
+</head>
+<body>
+<header>
+	<h1>Webmonitor</h1>
+	<span id="status" class="down">Connecting...</span>
+</header>
+<main>
+	<section>
+		<h2 id="summary-title">Statistics</h2>
+		<div id="timeframes"></div>
+		<p class="hint">Click a website or press its id to view its details, press s to switch timeframes. Response times are in ms.</p>
+		<table id="summary">
+			<thead><tr><th>Id</th><th>Website</th><th>Avg</th><th>Max</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th><th>Avail.</th><th>Apdex</th></tr></thead>
+			<tbody></tbody>
+		</table>
+	</section>
+	<section id="alerts-panel">
+		<h2>Alerts</h2>
+		<ul id="alerts"></ul>
+	</section>
+	<section>
+		<h2 id="details-title">Details</h2>
+		<table id="details">
+			<thead><tr><th>Time</th><th>Avg</th><th>Max</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th><th>Avail.</th><th>Apdex</th><th>Codes</th></tr></thead>
+			<tbody></tbody>
+		</table>
+		<svg id="chart" preserveAspectRatio="none"></svg>
+		<p class="hint" id="chart-legend">Recent response time</p>
+	</section>
+</main>
+<script>
+(function () {
+	"use strict";
+	var state = { timeframes: [], websites: [], alerts: [] };
+	var activeTimeframe = 0;
+	var activeWebsite = null;
+
+	function cell(text, className) {
+		var td = document.createElement("td");
+		td.textContent = text;
+		if (className) {
+			td.className = className;
+		}
+		return td;
+	}
+
+	function number(value, digits) {
+		return value === null || value === undefined ? "-" : value.toFixed(digits);
+	}
+
+	function percent(value) {
+		return value === null || value === undefined ? "-" : (value * 100).toFixed(0) + "%";
+	}
+
+	function codes(statusCodes) {
+		return Object.keys(statusCodes || {}).sort(function (a, b) {
+			return statusCodes[b] - statusCodes[a];
+		}).map(function (code) {
+			return code + ":" + statusCodes[code];
+		}).join(", ");
+	}
+
+	function statsCells(row, stats) {
+		row.appendChild(cell(number(stats.averageMs, 0)));
+		row.appendChild(cell(number(stats.maxMs, 0)));
+		row.appendChild(cell(number(stats.p50Ms, 0)));
+		row.appendChild(cell(number(stats.p90Ms, 0)));
+		row.appendChild(cell(number(stats.p95Ms, 0)));
+		row.appendChild(cell(number(stats.p99Ms, 0)));
+		row.appendChild(cell(percent(stats.availability), stats.availability !== null && stats.availability < 0.8 ? "down" : ""));
+		row.appendChild(cell(number(stats.apdex, 2)));
+	}
+
+	function website() {
+		for (var i = 0; i < state.websites.length; i++) {
+			if (state.websites[i].id === activeWebsite) {
+				return state.websites[i];
+			}
+		}
+		return state.websites[0];
+	}
+
+	function renderTimeframes() {
+		var container = document.getElementById("timeframes");
+		container.textContent = "";
+		state.timeframes.forEach(function (timeframe, index) {
+			var button = document.createElement("button");
+			button.textContent = timeframe;
+			button.className = index === activeTimeframe ? "active" : "";
+			button.onclick = function () {
+				activeTimeframe = index;
+				render();
+			};
+			container.appendChild(button);
+			container.appendChild(document.createTextNode(" "));
+		});
+		document.getElementById("summary-title").textContent = "Statistics : last " + (state.timeframes[activeTimeframe] || "");
+	}
+
+	function renderSummary() {
+		var body = document.querySelector("#summary tbody");
+		body.textContent = "";
+		var selected = website();
+		state.websites.forEach(function (site) {
+			var stats = site.stats[activeTimeframe];
+			var row = document.createElement("tr");
+			row.className = selected && site.id === selected.id ? "active" : "";
+			row.onclick = function () {
+				activeWebsite = site.id;
+				render();
+			};
+			row.appendChild(cell(site.id));
+			row.appendChild(cell(site.url, "url"));
+			statsCells(row, stats);
+			body.appendChild(row);
+		});
+	}
+
+	function renderDetails() {
+		var body = document.querySelector("#details tbody");
+		body.textContent = "";
+		var site = website();
+		document.getElementById("details-title").textContent = site ? "Details " + site.url : "Details";
+		if (!site) {
+			return;
+		}
+		site.stats.forEach(function (stats, index) {
+			if (stats.checks === 0) {
+				return;
+			}
+			var row = document.createElement("tr");
+			row.appendChild(cell(state.timeframes[index]));
+			statsCells(row, stats);
+			row.appendChild(cell(codes(stats.statusCodes)));
+			body.appendChild(row);
+		});
+		renderChart(site.responseTimesMs);
+	}
+
+	function renderChart(responseTimes) {
+		var chart = document.getElementById("chart");
+		var width = chart.clientWidth || 600;
+		var height = chart.clientHeight || 160;
+		chart.setAttribute("viewBox", "0 0 " + width + " " + height);
+		chart.textContent = "";
+		var legend = document.getElementById("chart-legend");
+		if (!responseTimes || responseTimes.length === 0) {
+			legend.textContent = "Recent response time : no checks yet";
+			return;
+		}
+		var max = Math.max.apply(null, responseTimes) || 1;
+		var step = responseTimes.length > 1 ? width / (responseTimes.length - 1) : width;
+		var points = responseTimes.map(function (value, index) {
+			return (index * step).toFixed(1) + "," + (height - 4 - (value / max) * (height - 8)).toFixed(1);
+		});
+		var line = document.createElementNS("http://www.w3.org/2000/svg", "polyline");
+		line.setAttribute("points", points.join(" "));
+		line.setAttribute("fill", "none");
+		line.setAttribute("stroke", "#50c050");
+		line.setAttribute("stroke-width", "1.5");
+		chart.appendChild(line);
+		legend.textContent = "Recent response time, last " + responseTimes.length + " checks, up to " + max.toFixed(0) + " ms";
+	}
+
+	function renderAlerts() {
+		var list = document.getElementById("alerts");
+		list.textContent = "";
+		if (state.alerts.length === 0) {
+			var empty = document.createElement("li");
+			empty.className = "empty";
+			empty.textContent = "There are no alerts.";
+			list.appendChild(empty);
+			return;
+		}
+		state.alerts.forEach(function (alert) {
+			var item = document.createElement("li");
+			var time = document.createElement("time");
+			time.textContent = new Date(alert.time).toLocaleTimeString();
+			item.appendChild(time);
+			var message = document.createElement("span");
+			message.className = alert.state === "fired" ? "down" : "up";
+			message.textContent = alert.message;
+			item.appendChild(message);
+			list.appendChild(item);
+		});
+	}
+
+	function render() {
+		if (activeTimeframe >= state.timeframes.length) {
+			activeTimeframe = 0;
+		}
+		renderTimeframes();
+		renderSummary();
+		renderDetails();
+		renderAlerts();
+	}
+
+	function connect() {
+		var status = document.getElementById("status");
+		var source = new EventSource("events");
+		source.onopen = function () {
+			status.textContent = "Live";
+			status.className = "up";
+		};
+		source.onerror = function () {
+			status.textContent = "Disconnected, reconnecting...";
+			status.className = "down";
+		};
+		source.addEventListener("state", function (e) {
+			state = JSON.parse(e.data);
+			render();
+		});
+		source.addEventListener("check", function (e) {
+			var check = JSON.parse(e.data);
+			state.websites.forEach(function (site) {
+				if (site.id === check.id) {
+					site.responseTimesMs.push(check.responseTimeMs);
+					if (site.responseTimesMs.length > 300) {
+						site.responseTimesMs.shift();
+					}
+				}
+			});
+			var site = website();
+			if (site && site.id === check.id) {
+				renderChart(site.responseTimesMs);
+			}
+		});
+		source.addEventListener("alert", function (e) {
+			state.alerts.unshift(JSON.parse(e.data));
+			if (state.alerts.length > 100) {
+				state.alerts.pop();
+			}
+			renderAlerts();
+		});
+	}
+
+	document.addEventListener("keydown", function (e) {
+		if (e.key === "s" && state.timeframes.length > 0) {
+			activeTimeframe = (activeTimeframe + 1) % state.timeframes.length;
+			render();
+		} else if (/^[0-9]$/.test(e.key)) {
+			var id = parseInt(e.key, 10);
+			state.websites.forEach(function (site) {
+				if (site.id === id) {
+					activeWebsite = id;
+					render();
+				}
+			});
+		}
+	});
+	window.addEventListener("resize", function () {
+		var site = website();
+		if (site) {
+			renderChart(site.responseTimesMs);
+		}
+	});
+	connect();
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,219 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// maxAlerts is the number of most recent alerts shown
+const maxAlerts = 100
+
+// chartPoints is the number of most recent response times shown in the chart
+const chartPoints = 300
+
+// clientBuffer is the number of events buffered for a client, beyond which its events are dropped until it catches up
+const clientBuffer = 64
+
+// Server serves the web dashboard, and streams its updates to the browsers as Server-Sent Events
+type Server struct {
+	mutex      sync.Mutex
+	timeframes []time.Duration
+	// Monitored websites, in the order they were added
+	websites []catalog.Website
+	// Alerts, from the oldest to the most recent
+	alerts []catalog.Alert
+	// Event channels of the connected browsers
+	clients map[chan event]bool
+	mux     *http.ServeMux
+}
+
+// event is a Server-Sent Event
+type event struct {
+	name string
+	data []byte
+}
+
+// state is the whole content of the dashboard
+type state struct {
+	// Statistics timeframes, such as 2min or 1h
+	Timeframes []string       `json:"timeframes"`
+	Websites   []websiteState `json:"websites"`
+	// Alerts, from the most recent to the oldest
+	Alerts []catalog.Alert `json:"alerts"`
+}
+
+// websiteState is the statistics of a website over each timeframe, and its recent response times over the longest one
+type websiteState struct {
+	ID            int                   `json:"id"`
+	URL           string                `json:"url"`
+	Stats         []statistics.Snapshot `json:"stats"`
+	ResponseTimes []float64             `json:"responseTimesMs"`
+}
+
+// checkState is the result of a check
+type checkState struct {
+	ID           int       `json:"id"`
+	Time         time.Time `json:"time"`
+	ResponseTime int       `json:"responseTimeMs"`
+	StatusCode   int       `json:"statusCode"`
+}
+
+// NewServer returns a new Server, showing the statistics of the given timeframes
+func NewServer(timeframes []time.Duration) *Server {
+	s := &Server{
+		timeframes: timeframes,
+		clients:    make(map[chan event]bool),
+		mux:        http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	return s
+}
+
+// SetWebsites sets the monitored websites, in their order
+func (s *Server) SetWebsites(websites []catalog.Website) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.websites = websites
+}
+
+// Record sends the result of a check of a website to the browsers
+func (s *Server) Record(url string, t time.Time, responseTime int, statusCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	site, ok := catalog.Find(s.websites, url)
+	if !ok {
+		return
+	}
+	s.broadcast("check", checkState{ID: site.ID, Time: t, ResponseTime: responseTime, StatusCode: statusCode})
+}
+
+// AddAlert adds a raised alert to the dashboard, and sends it to the browsers. Only the most recent alerts are kept.
+func (s *Server) AddAlert(alert catalog.Alert) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.alerts = append(s.alerts, alert)
+	if len(s.alerts) > maxAlerts {
+		s.alerts = append(s.alerts[:0], s.alerts[len(s.alerts)-maxAlerts:]...)
+	}
+	s.broadcast("alert", alert)
+}
+
+// Refresh sends the statistics of the websites to the browsers, if any
+func (s *Server) Refresh() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.clients) == 0 {
+		return
+	}
+	s.broadcast("state", s.state())
+}
+
+// ServeHTTP serves the dashboard
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// state returns the whole content of the dashboard. The mutex should be held.
+func (s *Server) state() state {
+	longest := 0
+	content := state{Websites: make([]websiteState, 0, len(s.websites)), Alerts: make([]catalog.Alert, 0, len(s.alerts))}
+	for index, timeframe := range s.timeframes {
+		content.Timeframes = append(content.Timeframes, cli.FormatDuration(timeframe))
+		if timeframe > s.timeframes[longest] {
+			longest = index
+		}
+	}
+	for _, site := range s.websites {
+		websiteState := websiteState{ID: site.ID, URL: site.Label}
+		for _, statistic := range site.Statistics {
+			websiteState.Stats = append(websiteState.Stats, statistic.Snapshot())
+		}
+		// Recent response times come from the most recent, and are charted from the oldest
+		recent := site.Statistics[longest].RecentResponseTime(chartPoints)
+		websiteState.ResponseTimes = make([]float64, len(recent))
+		for index, responseTime := range recent {
+			websiteState.ResponseTimes[len(recent)-index-1] = responseTime
+		}
+		content.Websites = append(content.Websites, websiteState)
+	}
+	for index := len(s.alerts) - 1; index >= 0; index-- {
+		content.Alerts = append(content.Alerts, s.alerts[index])
+	}
+	return content
+}
+
+// broadcast sends an event to every browser. Browsers too slow to keep up miss it. The mutex should be held.
+func (s *Server) broadcast(name string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	for client := range s.clients {
+		select {
+		case client <- event{name, data}:
+		default:
+		}
+	}
+}
+
+// handleIndex serves the page of the dashboard
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, indexHTML)
+}
+
+// handleEvents streams the state of the dashboard, then its updates, as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	client := make(chan event, clientBuffer)
+	s.mutex.Lock()
+	s.clients[client] = true
+	data, err := json.Marshal(s.state())
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.clients, client)
+		s.mutex.Unlock()
+	}()
+	if err != nil {
+		return
+	}
+	writeEvent(w, event{"state", data})
+	flusher.Flush()
+	for {
+		select {
+		case e := <-client:
+			writeEvent(w, e)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event
+func writeEvent(w http.ResponseWriter, e event) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, e.data)
+}
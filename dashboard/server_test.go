@@ -0,0 +1,119 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// nextEvent reads the next Server-Sent Event of a stream, and decodes its data
+func nextEvent(t *testing.T, reader *bufio.Reader, value interface{}) string {
+	var name, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the events failed : %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "event: ") {
+			name = strings.TrimPrefix(line, "event: ")
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if err := json.Unmarshal([]byte(data), value); err != nil {
+		t.Fatalf("event %s has invalid data %q : %v", name, data, err)
+	}
+	return name
+}
+
+func TestServer(t *testing.T) {
+	now := time.Now()
+	timeframes := []time.Duration{2 * time.Minute, 10 * time.Minute}
+	s := NewServer(timeframes)
+	urls := []string{"https://example.com", "https://example.org"}
+	var websites []catalog.Website
+	for id, url := range urls {
+		urlStatistics := []*statistics.Statistic{statistics.NewStatistic(timeframes[0], 500), statistics.NewStatistic(timeframes[1], 500)}
+		websites = append(websites, catalog.Website{ID: id, URL: url, Label: url, Statistics: urlStatistics})
+		for _, statistic := range urlStatistics {
+			statistic.AddRecord(now.Add(-time.Second), 100, 200)
+			statistic.AddRecord(now, 300, 200)
+		}
+	}
+	s.SetWebsites(websites)
+	// The first website is removed
+	s.SetWebsites(websites[1:])
+	s.AddAlert(catalog.Alert{Time: now, URL: urls[1], Name: "availability", State: catalog.Fired, Message: "down"})
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), "EventSource") {
+		t.Errorf("GET / == %d, want the dashboard page", resp.StatusCode)
+	}
+	if resp, err = http.Get(server.URL + "/missing"); err == nil && resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /missing == %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp, err = http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("GET /events Content-Type == %q, want text/event-stream", contentType)
+	}
+	reader := bufio.NewReader(resp.Body)
+	// The whole state first
+	var content state
+	if name := nextEvent(t, reader, &content); name != "state" {
+		t.Fatalf("first event == %s, want state", name)
+	}
+	if len(content.Timeframes) != 2 || content.Timeframes[1] != "10min" || len(content.Websites) != 1 || len(content.Alerts) != 1 {
+		t.Fatalf("state == %+v, want 2 timeframes, 1 website and 1 alert", content)
+	}
+	site := content.Websites[0]
+	if site.ID != 1 || site.URL != urls[1] || len(site.Stats) != 2 || site.Stats[1].Checks != 2 {
+		t.Errorf("state website == %+v", site)
+	}
+	// Response times are charted from the oldest
+	if len(site.ResponseTimes) != 2 || site.ResponseTimes[0] != 100 || site.ResponseTimes[1] != 300 {
+		t.Errorf("state response times == %v, want [100 300]", site.ResponseTimes)
+	}
+
+	// Then the updates
+	s.Record(urls[0], now, 200, 200)
+	s.Record(urls[1], now, 200, 500)
+	var check checkState
+	if name := nextEvent(t, reader, &check); name != "check" || check.ID != 1 || check.StatusCode != 500 {
+		t.Errorf("event %s %+v, want the check of the remaining website", name, check)
+	}
+	s.AddAlert(catalog.Alert{Time: now, URL: urls[1], Name: "availability", State: catalog.Resolved, Message: "up"})
+	var alert catalog.Alert
+	if name := nextEvent(t, reader, &alert); name != "alert" || alert.State != "resolved" {
+		t.Errorf("event %s %+v, want the resolved alert", name, alert)
+	}
+	s.Refresh()
+	content = state{}
+	if name := nextEvent(t, reader, &content); name != "state" || len(content.Alerts) != 2 || content.Alerts[0].State != "resolved" {
+		t.Errorf("event %s %+v, want the state with the alerts from the most recent", name, content)
+	}
+}
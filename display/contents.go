@@ -0,0 +1,34 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/hugo-sv/webmonitor/contents"
+)
+
+// renderContent renders the diff of the last two versions of the active website's content in the detailed view
+func renderContent(uiView View) {
+	rect := uiView.layout().detailBody
+	p := widgets.NewParagraph()
+	p.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	p.TextStyle.Fg = ui.ColorWhite
+	watcher, ok := uiView.Contents[uiView.Urls[uiView.ActiveWebsite]]
+	var versions []contents.Version
+	if ok {
+		versions = watcher.Versions()
+	}
+	switch {
+	case !ok:
+		p.Text = "Content change detection is disabled for this website"
+	case len(versions) < 2:
+		p.Text = "No content change"
+	default:
+		previous, current := versions[len(versions)-2], versions[len(versions)-1]
+		p.Title = fmt.Sprintf(" Changed at %v, %d versions kept ", current.Time.Format("Jan 2 15:04:05"), len(versions))
+		p.Text = fmt.Sprintf("%s -> %s\n%s", previous.Hash[:12], current.Hash[:12], strings.Join(contents.Diff(previous.Body, current.Body), "\n"))
+	}
+	ui.Render(p)
+}
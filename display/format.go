@@ -7,7 +7,7 @@ import (
 	"strings"
 )
 
-// Shorten shortens a URL by removing non relevant headers.
+// Shorten shortens a URL by removing non relevant headers. Its secrets should be redacted beforehand.
 func Shorten(url string) string {
 	r, _ := regexp.Compile("^(http(s)?://)?(www.)?")
 	return r.ReplaceAllString(url, "")
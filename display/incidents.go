@@ -0,0 +1,66 @@
+package display
+
+import (
+	"fmt"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/hugo-sv/webmonitor/incidents"
+)
+
+// renderIncidents renders the incidents of the active website in the detailed view
+func renderIncidents(uiView View) {
+	url := uiView.Urls[uiView.ActiveWebsite]
+	rect := uiView.layout().detailBody
+	// The first failure takes the remaining width, the end and duration are dropped from narrow tables
+	columns, columnWidths := fitColumns(rect.Dx(), []int{14, 14, 9, 6, 0}, 4, 16, []int{1, 2})
+	Table := [][]string{{"Start", "End", "Duration", "Checks", "First failure"}}
+	for _, incident := range uiView.Incidents.Incidents(url) {
+		end := "ongoing"
+		if !incident.Ongoing() {
+			end = incident.End.Format("Jan 2 15:04:05")
+		}
+		Table = append(Table, []string{
+			incident.Start.Format("Jan 2 15:04:05"),
+			end,
+			incident.Duration(time.Now()).Round(time.Second).String(),
+			fmt.Sprint(len(incident.Samples)),
+			incident.FirstFailure,
+		})
+	}
+	g := widgets.NewTable()
+	g.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	g.TextAlignment = ui.AlignCenter
+	g.ColumnWidths = columnWidths
+	if len(Table) == 1 {
+		Table = append(Table, []string{"", "", "", "", "No incidents"})
+	}
+	g.Rows = selectColumns(Table, columns)
+	for row := 1; row < len(Table); row++ {
+		if Table[row][1] == "ongoing" {
+			g.RowStyles[row] = ui.NewStyle(ui.ColorRed)
+		}
+	}
+	ui.Render(g)
+}
+
+// RenderIncidentClosed prints the summary of a closed incident without UI
+func RenderIncidentClosed(uiView View, incident incidents.Incident) {
+	if uiView.UIEnabled {
+		return
+	}
+	if uiView.Output == "json" {
+		renderIncidentJSON(uiView, incident)
+		return
+	}
+	fmt.Println("Incident :")
+	fmt.Printf("\tWebsite %s was down from %v to %v (%v), %d checks triggered it. First failure : %s\n",
+		Shorten(uiView.Labels[incident.URL]),
+		incident.Start.Format(time.Kitchen),
+		incident.End.Format(time.Kitchen),
+		incident.Duration(incident.End).Round(time.Second),
+		len(incident.Samples),
+		incident.FirstFailure,
+	)
+}
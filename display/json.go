@@ -0,0 +1,129 @@
+package display
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// checkEvent is the JSON event of a check result
+type checkEvent struct {
+	Time         time.Time `json:"time"`
+	Event        string    `json:"event"`
+	URL          string    `json:"url"`
+	ResponseTime int       `json:"responseTimeMs"`
+	StatusCode   int       `json:"statusCode"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// statsEvent is the JSON event of the statistics of a website over a timeframe
+type statsEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	URL       string    `json:"url"`
+	Timeframe float64   `json:"timeframeSeconds"`
+	statistics.Snapshot
+	Objectives []statistics.ObjectiveSnapshot `json:"objectives,omitempty"`
+}
+
+// alertEvent is the JSON event of an alert firing or resolving
+type alertEvent struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	URL       string    `json:"url"`
+	Alert     string    `json:"alert"`
+	Objective string    `json:"objective,omitempty"`
+	State     string    `json:"state"`
+	Message   string    `json:"message"`
+}
+
+// incidentEvent is the JSON event of a closed incident
+type incidentEvent struct {
+	Time         time.Time `json:"time"`
+	Event        string    `json:"event"`
+	URL          string    `json:"url"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Duration     float64   `json:"durationSeconds"`
+	Checks       int       `json:"checks"`
+	FirstFailure string    `json:"firstFailure"`
+}
+
+// jsonMutex prevents the events written by concurrent renders from interleaving
+var jsonMutex sync.Mutex
+
+// writeEvent writes an event as a line of JSON on the standard output
+func writeEvent(event interface{}) {
+	jsonMutex.Lock()
+	defer jsonMutex.Unlock()
+	json.NewEncoder(os.Stdout).Encode(event)
+}
+
+// RenderCheck writes the result of a check, only with the JSON output. Its failure reason should be redacted.
+func RenderCheck(uiView View, stats monitor.CheckStats) {
+	if uiView.UIEnabled || uiView.Output != "json" {
+		return
+	}
+	writeEvent(checkEvent{
+		Time:         stats.Time,
+		Event:        "check",
+		URL:          uiView.Labels[stats.URL],
+		ResponseTime: stats.ResponseTime,
+		StatusCode:   stats.StatusCode,
+		Reason:       stats.Reason,
+	})
+}
+
+// RenderAlert renders a newly raised alert
+func RenderAlert(uiView View, alert catalog.Alert) {
+	if uiView.UIEnabled || uiView.Output != "json" {
+		RenderAlerts(uiView)
+		return
+	}
+	writeEvent(alertEvent{
+		Time:      alert.Time,
+		Event:     "alert",
+		URL:       alert.URL,
+		Alert:     alert.Name,
+		Objective: alert.Objective,
+		State:     alert.State,
+		Message:   alert.Message,
+	})
+}
+
+// renderStatsJSON writes the statistics of each website over a timeframe
+func renderStatsJSON(uiView View, timeframe int) {
+	for _, url := range uiView.Urls {
+		event := statsEvent{
+			Time:      time.Now(),
+			Event:     "stats",
+			URL:       uiView.Labels[url],
+			Timeframe: uiView.Timeframes[timeframe].Seconds(),
+			Snapshot:  uiView.URLStatistics[url][timeframe].Snapshot(),
+		}
+		for _, objective := range uiView.URLObjectives[url] {
+			event.Objectives = append(event.Objectives, objective.Snapshot())
+		}
+		writeEvent(event)
+	}
+}
+
+// renderIncidentJSON writes a closed incident
+func renderIncidentJSON(uiView View, incident incidents.Incident) {
+	writeEvent(incidentEvent{
+		Time:         incident.End,
+		Event:        "incident",
+		URL:          uiView.Labels[incident.URL],
+		Start:        incident.Start,
+		End:          incident.End,
+		Duration:     incident.Duration(incident.End).Seconds(),
+		Checks:       len(incident.Samples),
+		FirstFailure: incident.FirstFailure,
+	})
+}
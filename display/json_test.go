@@ -0,0 +1,150 @@
+package display
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// captureEvents returns the JSON events written on the standard output by render
+func captureEvents(t *testing.T, render func()) []map[string]interface{} {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	render()
+	os.Stdout = stdout
+	writer.Close()
+	events := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("event %s is not valid JSON : %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// keys returns the sorted keys of an event
+func keys(event map[string]interface{}) []string {
+	names := make([]string, 0, len(event))
+	for name := range event {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsonView returns a view of a website with the JSON output
+func jsonView() View {
+	url := "https://example.com/?token=secret"
+	return View{
+		Output:        "json",
+		Urls:          []string{url},
+		Labels:        map[string]string{url: "https://example.com/?token=****"},
+		Timeframes:    []time.Duration{time.Minute},
+		URLStatistics: map[string][]*statistics.Statistic{url: {statistics.NewStatistic(time.Minute, 500)}},
+	}
+}
+
+func TestRenderCheckJSON(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	events := captureEvents(t, func() {
+		RenderCheck(jsonView(), monitor.CheckStats{URL: "https://example.com/?token=secret", Time: now, ResponseTime: 5000, StatusCode: 408, Reason: "timeout"})
+		RenderCheck(jsonView(), monitor.CheckStats{URL: "https://example.com/?token=secret", Time: now, ResponseTime: 120, StatusCode: 200})
+	})
+	if len(events) != 2 {
+		t.Fatalf("events == %v, want 2 checks", events)
+	}
+	if want := []string{"event", "reason", "responseTimeMs", "statusCode", "time", "url"}; !reflect.DeepEqual(keys(events[0]), want) {
+		t.Errorf("check keys == %v, want %v", keys(events[0]), want)
+	}
+	if events[0]["event"] != "check" || events[0]["url"] != "https://example.com/?token=****" || events[0]["statusCode"] != 408.0 || events[0]["time"] != "2020-01-01T12:00:00Z" {
+		t.Errorf("check == %v, want the redacted URL and the failed check", events[0])
+	}
+	// Successful checks have no reason
+	if _, ok := events[1]["reason"]; ok {
+		t.Errorf("check == %v, want no reason", events[1])
+	}
+	// Nothing is written with the UI
+	uiView := jsonView()
+	uiView.UIEnabled = true
+	if events := captureEvents(t, func() { RenderCheck(uiView, monitor.CheckStats{}) }); len(events) != 0 {
+		t.Errorf("events with the UI == %v, want none", events)
+	}
+}
+
+func TestRenderStatsJSON(t *testing.T) {
+	events := captureEvents(t, func() { renderStatsJSON(jsonView(), 0) })
+	if len(events) != 1 {
+		t.Fatalf("events == %v, want the stats of a website", events)
+	}
+	want := []string{"apdex", "availability", "averageMs", "checks", "event", "maxMs", "minMs", "p50Ms", "p90Ms", "p95Ms", "p99Ms", "statusCodes", "time", "timeframeSeconds", "url"}
+	if !reflect.DeepEqual(keys(events[0]), want) {
+		t.Errorf("stats keys == %v, want %v", keys(events[0]), want)
+	}
+	// Without checks, the statistics are null rather than NaN
+	for _, name := range []string{"apdex", "availability", "averageMs", "minMs", "maxMs", "p50Ms", "p99Ms"} {
+		if events[0][name] != nil {
+			t.Errorf("stats %s == %v without checks, want null", name, events[0][name])
+		}
+	}
+	if events[0]["event"] != "stats" || events[0]["checks"] != 0.0 || events[0]["timeframeSeconds"] != 60.0 {
+		t.Errorf("stats == %v, want the stats of a minute without checks", events[0])
+	}
+}
+
+func TestRenderAlertJSON(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	events := captureEvents(t, func() {
+		RenderAlert(jsonView(), catalog.Alert{Time: now, URL: "https://example.com/?token=****", Name: "fast_burn", Objective: "availability", State: catalog.Fired, Message: "burning"})
+		RenderAlert(jsonView(), catalog.Alert{Time: now, URL: "https://example.com/?token=****", Name: "availability", State: catalog.Resolved, Message: "up"})
+	})
+	if len(events) != 2 {
+		t.Fatalf("events == %v, want 2 alerts", events)
+	}
+	if want := []string{"alert", "event", "message", "objective", "state", "time", "url"}; !reflect.DeepEqual(keys(events[0]), want) {
+		t.Errorf("alert keys == %v, want %v", keys(events[0]), want)
+	}
+	if events[0]["event"] != "alert" || events[0]["alert"] != "fast_burn" || events[0]["state"] != "fired" {
+		t.Errorf("alert == %v, want the fired burn alert", events[0])
+	}
+	// Alerts about no objective have none
+	if want := []string{"alert", "event", "message", "state", "time", "url"}; !reflect.DeepEqual(keys(events[1]), want) || events[1]["state"] != "resolved" {
+		t.Errorf("alert == %v, want the resolved availability alert", events[1])
+	}
+}
+
+func TestRenderIncidentJSON(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	incident := incidents.Incident{
+		URL:          "https://example.com/?token=secret",
+		Start:        start,
+		End:          start.Add(90 * time.Second),
+		FirstFailure: "timeout",
+		Samples:      []incidents.Sample{{Time: start, StatusCode: 408}, {Time: start.Add(time.Minute), StatusCode: 500}},
+	}
+	events := captureEvents(t, func() { renderIncidentJSON(jsonView(), incident) })
+	if len(events) != 1 {
+		t.Fatalf("events == %v, want an incident", events)
+	}
+	if want := []string{"checks", "durationSeconds", "end", "event", "firstFailure", "start", "time", "url"}; !reflect.DeepEqual(keys(events[0]), want) {
+		t.Errorf("incident keys == %v, want %v", keys(events[0]), want)
+	}
+	if events[0]["event"] != "incident" || events[0]["url"] != "https://example.com/?token=****" || events[0]["durationSeconds"] != 90.0 || events[0]["checks"] != 2.0 || events[0]["time"] != "2020-01-01T12:01:30Z" {
+		t.Errorf("incident == %v, want the redacted incident of 90 seconds", events[0])
+	}
+}
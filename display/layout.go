@@ -0,0 +1,173 @@
+package display
+
+import (
+	"image"
+)
+
+// Terminal sizes of the layout
+const (
+	// Width from which the alerts are shown next to the statistics rather than below them
+	wideWidth = 120
+	// Smallest terminal in which the panels are rendered
+	minWidth  = 40
+	minHeight = 16
+	// Size the layout is computed for until the terminal size is known
+	defaultWidth  = 150
+	defaultHeight = 50
+)
+
+// layout is the position of every panel in the terminal, computed from its size
+type layout struct {
+	header image.Rectangle
+	// Statistics panel, and its table of every website
+	statistics image.Rectangle
+	statsTable image.Rectangle
+	// Details panel, its table of every timeframe, uptime timeline and latency heatmap, or its incidents or content body
+	details     image.Rectangle
+	detailTable image.Rectangle
+	timeline    image.Rectangle
+	heatmap     image.Rectangle
+	detailBody  image.Rectangle
+	// Alerts panel and its messages
+	alerts     image.Rectangle
+	alertsText image.Rectangle
+	// Service level objectives panel, empty when it is collapsed
+	slo image.Rectangle
+	// Whether the terminal is too small to render the panels
+	tooSmall bool
+}
+
+// size returns the size of the terminal, or the default size until it is known
+func (uiView View) size() (int, int) {
+	if uiView.Width == 0 || uiView.Height == 0 {
+		return defaultWidth, defaultHeight
+	}
+	return uiView.Width, uiView.Height
+}
+
+// layout returns the position of the panels. Wide terminals show the alerts and SLOs on the right of the statistics,
+// narrow ones show the alerts in a strip below them and collapse the SLO panel.
+func (uiView View) layout() layout {
+	width, height := uiView.size()
+	var l layout
+	if width < minWidth || height < minHeight {
+		l.tooSmall = true
+		return l
+	}
+	left := width
+	bottom := height
+	if width >= wideWidth {
+		left = width / 2
+		alertsBottom := height
+		if uiView.hasObjectives() {
+			alertsBottom = height * 16 / 25
+			l.slo = image.Rect(left, alertsBottom, width, height)
+		}
+		l.alerts = image.Rect(left, 0, width, alertsBottom)
+	} else {
+		bottom = height - clamp(height/5, 5, 10)
+		l.alerts = image.Rect(0, bottom, width, height)
+	}
+	l.alertsText = image.Rect(l.alerts.Min.X, l.alerts.Min.Y+2, l.alerts.Max.X, l.alerts.Max.Y)
+
+	l.header = image.Rect(0, 0, left, 3)
+	// The statistics take a half of the remaining height, the details the other half
+	middle := 3 + (bottom-3)/2
+	l.statistics = image.Rect(0, 3, left, middle)
+	l.statsTable = image.Rect(0, 5, left, middle)
+	l.details = image.Rect(0, middle, left, bottom)
+	l.detailBody = image.Rect(0, middle+2, left, bottom-1)
+	// The detail table has a row per timeframe, the timeline and heatmap the rest of the panel if it is high enough
+	tableBottom := middle + 5 + 2*len(uiView.Timeframes)
+	if tableBottom > bottom-1 {
+		tableBottom = bottom - 1
+	}
+	l.detailTable = image.Rect(0, middle+2, left, tableBottom)
+	if bottom-1-tableBottom >= 3 {
+		l.timeline = image.Rect(0, tableBottom, left, tableBottom+3)
+	}
+	if bottom-1-tableBottom >= 7 {
+		l.heatmap = image.Rect(0, tableBottom+3, left, bottom-1)
+	}
+	return l
+}
+
+// clamp returns a value within a minimum and a maximum
+func clamp(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// fitColumns returns the columns of a table fitting within a width, and their widths. The flexible column takes the
+// remaining width, and the droppable columns are dropped in order until it is at least its minimum width.
+func fitColumns(width int, widths []int, flexible int, minFlexible int, droppable []int) ([]int, []int) {
+	dropped := make(map[int]bool)
+	for {
+		// Each column is followed by a separator, the last one being drawn over the right border of the table
+		remaining := width - 1
+		for column, columnWidth := range widths {
+			if !dropped[column] {
+				remaining--
+				if column != flexible {
+					remaining -= columnWidth
+				}
+			}
+		}
+		if remaining >= minFlexible || len(dropped) == len(droppable) {
+			var columns, columnWidths []int
+			for column, columnWidth := range widths {
+				if dropped[column] {
+					continue
+				}
+				if column == flexible {
+					columnWidth = maxInt(remaining, 1)
+				}
+				columns = append(columns, column)
+				columnWidths = append(columnWidths, columnWidth)
+			}
+			return columns, columnWidths
+		}
+		dropped[droppable[len(dropped)]] = true
+	}
+}
+
+// selectColumns returns the rows of a table with only some of their columns
+func selectColumns(rows [][]string, columns []int) [][]string {
+	selected := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		selectedRow := make([]string, 0, len(columns))
+		for _, column := range columns {
+			selectedRow = append(selectedRow, row[column])
+		}
+		selected = append(selected, selectedRow)
+	}
+	return selected
+}
+
+// maxInt returns the biggest of two integers
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Truncate shortens a text to a width, replacing its middle with an ellipsis so that both the host and the end of
+// the path of a URL stay visible.
+func Truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	if width <= 1 {
+		return string(runes[:maxInt(width, 0)])
+	}
+	head := width / 2
+	tail := width - 1 - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
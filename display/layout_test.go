@@ -0,0 +1,68 @@
+package display
+
+import (
+	"image"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+func TestLayout(t *testing.T) {
+	timeframes := []time.Duration{2 * time.Minute, 10 * time.Minute}
+	// Wide terminals show the alerts on the right, above the SLOs
+	wide := View{Width: 200, Height: 50, Timeframes: timeframes, URLObjectives: map[string][]*statistics.Objective{"https://example.com": {{}}}}.layout()
+	if wide.alerts != image.Rect(100, 0, 200, 32) || wide.slo != image.Rect(100, 32, 200, 50) || wide.statistics.Max.X != 100 {
+		t.Errorf("wide layout == %+v, want the alerts and SLOs on the right half", wide)
+	}
+	// Narrow terminals show the alerts below the statistics, without SLOs
+	narrow := View{Width: 80, Height: 24, Timeframes: timeframes, URLObjectives: map[string][]*statistics.Objective{"https://example.com": {{}}}}.layout()
+	if narrow.alerts != image.Rect(0, 19, 80, 24) || !narrow.slo.Empty() || narrow.details.Max.Y != 19 {
+		t.Errorf("narrow layout == %+v, want the alerts in a strip at the bottom", narrow)
+	}
+	if !narrow.timeline.Empty() || !narrow.heatmap.Empty() {
+		t.Errorf("narrow timeline == %v and heatmap == %v, want them collapsed", narrow.timeline, narrow.heatmap)
+	}
+	if wide.timeline != image.Rect(0, 35, 100, 38) || wide.heatmap != image.Rect(0, 38, 100, 49) {
+		t.Errorf("wide timeline == %v and heatmap == %v, want them below the detail table", wide.timeline, wide.heatmap)
+	}
+	// The default size is used until the terminal size is known
+	if l := (View{Timeframes: timeframes}).layout(); l.alerts != image.Rect(75, 0, 150, 50) {
+		t.Errorf("default alerts == %v, want the right half of 150x50", l.alerts)
+	}
+	if l := (View{Width: 30, Height: 50}).layout(); !l.tooSmall {
+		t.Errorf("layout of 30x50 == %+v, want too small", l)
+	}
+}
+
+func TestFitColumns(t *testing.T) {
+	// 75 columns : the left border and 10 separators leave 16 columns for the website
+	columns, columnWidths := fitColumns(75, statsColumnWidths, 1, 12, droppedColumns)
+	if len(columns) != 10 || columnWidths[1] != 16 {
+		t.Errorf("fitColumns(75) == %v, %v, want every column and a website of 16", columns, columnWidths)
+	}
+	// 56 columns drop p50, p90 and p99
+	columns, columnWidths = fitColumns(56, statsColumnWidths, 1, 12, droppedColumns)
+	if !reflect.DeepEqual(columns, []int{0, 1, 2, 3, 6, 8, 9}) || columnWidths[1] != 15 {
+		t.Errorf("fitColumns(56) == %v, %v, want p50, p90 and p99 dropped", columns, columnWidths)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"https://example.com", 30, "https://example.com"},
+		{"https://example.com/a/long/path", 11, "https…/path"},
+		{"https://example.com", 1, "h"},
+		{"https://example.com", 0, ""},
+	}
+	for _, test := range tests {
+		if got := Truncate(test.text, test.width); got != test.want {
+			t.Errorf("Truncate(%q, %d) == %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package display
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// statsHeaders are the columns of the statistics table, by which the websites can be sorted
+var statsHeaders = []string{"Id", "Website", "Avg", "Max", "p50", "p90", "p95", "p99", "Avail.", "Apdex"}
+
+// websiteRow is a row of the statistics table
+type websiteRow struct {
+	// Index of the URL of the row
+	index int
+	cells []string
+	// Values of the columns the row is sorted by, NaN without statistics
+	values []float64
+}
+
+// NextSortColumn returns the column following the one the websites are sorted by, cycling through the columns
+func (uiView View) NextSortColumn() int {
+	return (uiView.SortColumn + 1) % len(statsHeaders)
+}
+
+// matches returns whether a URL matches the filter, by a substring of its redacted URL or of one of its key=value tags
+func (uiView View) matches(url string) bool {
+	filter := strings.ToLower(uiView.Filter)
+	if filter == "" || strings.Contains(strings.ToLower(uiView.Labels[url]), filter) {
+		return true
+	}
+	for key, value := range uiView.Tags[url] {
+		if strings.Contains(strings.ToLower(key+"="+value), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// websiteRows returns the rows of the websites matching the filter, sorted by the sort column. Websites without
+// statistics are listed last.
+func (uiView View) websiteRows() []websiteRow {
+	rows := make([]websiteRow, 0, len(uiView.Urls))
+	for index, url := range uiView.Urls {
+		if !uiView.matches(url) {
+			continue
+		}
+		row := websiteRow{index: index, cells: []string{fmt.Sprint(uiView.IDs[url]), Shorten(uiView.Labels[url]), "-", "-", "-", "-", "-", "-", "-", "-"}}
+		row.values = []float64{float64(uiView.IDs[url]), 0, math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()}
+		urlStatistic := uiView.URLStatistics[url][uiView.ActiveTimeframe]
+		if !math.IsNaN(urlStatistic.Average()) {
+			row.values = []float64{
+				float64(uiView.IDs[url]),
+				0,
+				urlStatistic.Average(),
+				float64(urlStatistic.MaxResponseTime()),
+				urlStatistic.Percentile(50),
+				urlStatistic.Percentile(90),
+				urlStatistic.Percentile(95),
+				urlStatistic.Percentile(99),
+				urlStatistic.Availability() * 100.0,
+				urlStatistic.Apdex(),
+			}
+			row.cells = []string{
+				row.cells[0],
+				row.cells[1],
+				fmt.Sprintf("%.0f", row.values[2]),
+				fmt.Sprintf("%v", urlStatistic.MaxResponseTime()),
+				fmt.Sprintf("%.0f", row.values[4]),
+				fmt.Sprintf("%.0f", row.values[5]),
+				fmt.Sprintf("%.0f", row.values[6]),
+				fmt.Sprintf("%.0f", row.values[7]),
+				fmt.Sprintf("%.0f%%", row.values[8]),
+				fmt.Sprintf("%.2f", row.values[9]),
+			}
+		}
+		rows = append(rows, row)
+	}
+	column := uiView.SortColumn
+	sort.SliceStable(rows, func(i, j int) bool {
+		if column == 1 {
+			if uiView.SortDescending {
+				return rows[i].cells[1] > rows[j].cells[1]
+			}
+			return rows[i].cells[1] < rows[j].cells[1]
+		}
+		a, b := rows[i].values[column], rows[j].values[column]
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return !math.IsNaN(a) && math.IsNaN(b)
+		}
+		if uiView.SortDescending {
+			return a > b
+		}
+		return a < b
+	})
+	return rows
+}
+
+// VisibleWebsites returns the indexes of the URLs listed in the statistics table, in their order
+func (uiView View) VisibleWebsites() []int {
+	rows := uiView.websiteRows()
+	indexes := make([]int, 0, len(rows))
+	for _, row := range rows {
+		indexes = append(indexes, row.index)
+	}
+	return indexes
+}
+
+// MoveSelection selects the website listed a number of rows below the active one, or above it if negative, and
+// scrolls the list to it. The first listed website is selected if the active one is not listed.
+func (uiView *View) MoveSelection(delta int) {
+	visible := uiView.VisibleWebsites()
+	if len(visible) == 0 {
+		return
+	}
+	position := -1
+	for i, index := range visible {
+		if index == uiView.ActiveWebsite {
+			position = i
+		}
+	}
+	if position == -1 {
+		position = 0
+	} else {
+		position = clamp(position+delta, 0, len(visible)-1)
+	}
+	uiView.ActiveWebsite = visible[position]
+	uiView.WebsiteOffset = scrollOffset(uiView.WebsiteOffset, position, uiView.websiteCapacity(), len(visible))
+}
+
+// websiteCapacity returns the number of websites the statistics table can list, below its headers
+func (uiView View) websiteCapacity() int {
+	return maxInt(uiView.layout().statsTable.Dy()-3, 1)
+}
+
+// scrollOffset returns the scroll position of a list showing a capacity of its rows, moved as little as possible from
+// an offset so that a position is shown
+func scrollOffset(offset int, position int, capacity int, count int) int {
+	if position < offset {
+		offset = position
+	}
+	if position >= offset+capacity {
+		offset = position - capacity + 1
+	}
+	return clamp(offset, 0, maxInt(count-capacity, 0))
+}
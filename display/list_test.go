@@ -0,0 +1,96 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// listView returns a view of websites whose statistics have a check of a response time each, or none if negative
+func listView(responseTimes map[string]int) View {
+	uiView := View{
+		IDs:           make(map[string]int),
+		Labels:        make(map[string]string),
+		URLStatistics: make(map[string][]*statistics.Statistic),
+		Tags:          make(map[string]map[string]string),
+		Timeframes:    []time.Duration{time.Minute},
+		Width:         150,
+		Height:        50,
+	}
+	for _, url := range []string{"https://a.example.com", "https://b.example.com", "https://c.example.org", "https://d.example.org"} {
+		uiView.IDs[url] = len(uiView.Urls)
+		uiView.Labels[url] = url
+		uiView.Urls = append(uiView.Urls, url)
+		statistic := statistics.NewStatistic(time.Minute, 0)
+		if responseTimes[url] >= 0 {
+			statistic.AddRecord(time.Now(), responseTimes[url], 200)
+		}
+		uiView.URLStatistics[url] = []*statistics.Statistic{statistic}
+	}
+	uiView.Tags["https://c.example.org"] = map[string]string{"env": "prod"}
+	return uiView
+}
+
+func TestWebsiteRows(t *testing.T) {
+	uiView := listView(map[string]int{"https://a.example.com": 300, "https://b.example.com": -1, "https://c.example.org": 100, "https://d.example.org": 200})
+	if got := uiView.VisibleWebsites(); !reflect.DeepEqual(got, []int{0, 1, 2, 3}) {
+		t.Errorf("VisibleWebsites() == %v, want the websites by id", got)
+	}
+	// Websites without statistics are listed last in both orders
+	uiView.SortColumn = 2
+	if got := uiView.VisibleWebsites(); !reflect.DeepEqual(got, []int{2, 3, 0, 1}) {
+		t.Errorf("VisibleWebsites() by average == %v, want [2 3 0 1]", got)
+	}
+	uiView.SortDescending = true
+	if got := uiView.VisibleWebsites(); !reflect.DeepEqual(got, []int{0, 3, 2, 1}) {
+		t.Errorf("VisibleWebsites() by descending average == %v, want [0 3 2 1]", got)
+	}
+	// Filtering by URL substring or tag
+	uiView.SortColumn, uiView.SortDescending = 0, false
+	uiView.Filter = "EXAMPLE.org"
+	if got := uiView.VisibleWebsites(); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("VisibleWebsites() matching %q == %v, want [2 3]", uiView.Filter, got)
+	}
+	uiView.Filter = "env=prod"
+	if got := uiView.VisibleWebsites(); !reflect.DeepEqual(got, []int{2}) {
+		t.Errorf("VisibleWebsites() matching %q == %v, want [2]", uiView.Filter, got)
+	}
+}
+
+func TestMoveSelection(t *testing.T) {
+	uiView := listView(nil)
+	uiView.MoveSelection(2)
+	if uiView.ActiveWebsite != 2 {
+		t.Errorf("ActiveWebsite == %v, want 2", uiView.ActiveWebsite)
+	}
+	uiView.MoveSelection(10)
+	if uiView.ActiveWebsite != 3 {
+		t.Errorf("ActiveWebsite == %v, want the last website 3", uiView.ActiveWebsite)
+	}
+	// The first listed website is selected once the active one is filtered out
+	uiView.Filter = "example.com"
+	uiView.MoveSelection(0)
+	if uiView.ActiveWebsite != 0 {
+		t.Errorf("ActiveWebsite == %v, want the first matching website 0", uiView.ActiveWebsite)
+	}
+}
+
+func TestScrollOffset(t *testing.T) {
+	tests := []struct {
+		offset, position, capacity, count int
+		want                              int
+	}{
+		{0, 5, 10, 140, 0},
+		{0, 10, 10, 140, 1},
+		{20, 15, 10, 140, 15},
+		{130, 139, 10, 140, 130},
+		{50, 2, 10, 5, 0},
+	}
+	for _, test := range tests {
+		if got := scrollOffset(test.offset, test.position, test.capacity, test.count); got != test.want {
+			t.Errorf("scrollOffset(%d, %d, %d, %d) == %d, want %d", test.offset, test.position, test.capacity, test.count, got, test.want)
+		}
+	}
+}
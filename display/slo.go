@@ -0,0 +1,104 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// sloColumnWidths are the widths of the columns of the SLO table, whose website column takes the remaining width
+var sloColumnWidths = []int{0, 12, 7, 7, 7, 6, 6}
+
+// hasObjectives returns whether a website has service level objectives
+func (uiView View) hasObjectives() bool {
+	for _, objectives := range uiView.URLObjectives {
+		if len(objectives) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSLOLayout renders the SLO Layout
+func renderSLOLayout(rect image.Rectangle) {
+	p := widgets.NewParagraph()
+	p.Title = " Service Level Objectives "
+	p.Text = "SLOs are loading ..."
+	p.TextStyle.Fg = ui.ColorYellow
+	p.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	p.BorderStyle.Fg = ui.ColorCyan
+	ui.Render(p)
+}
+
+// RenderSLOs renders the compliance, error budget and burn rates of the service level objectives
+func RenderSLOs(uiView View) {
+	rect := uiView.layout().slo
+	if !uiView.UIEnabled || !uiView.hasObjectives() || rect.Empty() {
+		// The SLO panel is collapsed in narrow terminals
+		return
+	}
+	Table := [][]string{{"Website", "SLO", "Target", "Actual", "Budget", "Fast", "Slow"}}
+	columns, columnWidths := fitColumns(rect.Dx(), sloColumnWidths, 0, 12, nil)
+	for _, url := range uiView.Urls {
+		for _, objective := range uiView.URLObjectives[url] {
+			Table = append(Table, append([]string{Truncate(Shorten(uiView.Labels[url]), columnWidths[0])}, objectiveRow(objective)...))
+		}
+	}
+	g := widgets.NewTable()
+	g.Title = " Service Level Objectives "
+	g.TitleStyle.Fg = ui.ColorWhite
+	g.BorderStyle.Fg = ui.ColorCyan
+	g.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	g.TextAlignment = ui.AlignCenter
+	g.ColumnWidths = columnWidths
+	g.Rows = selectColumns(Table, columns)
+	// Highlighting exhausted error budgets
+	for row := 1; row < len(Table); row++ {
+		if Table[row][4] != "-" && Table[row][4][0] == '-' {
+			g.RowStyles[row] = ui.NewStyle(ui.ColorRed)
+		}
+	}
+	ui.Render(g)
+}
+
+// objectiveRow returns the target, compliance, remaining budget and fast and slow burn rates of an objective
+func objectiveRow(objective *statistics.Objective) []string {
+	fastLong, _ := objective.BurnWindows(true)
+	slowLong, _ := objective.BurnWindows(false)
+	return []string{
+		objective.Name,
+		fmt.Sprintf("%v%%", objective.Target*100.0),
+		formatPercentage(objective.Compliance(), 2),
+		formatPercentage(objective.ErrorBudgetRemaining(), 0),
+		formatRate(objective.BurnRate(fastLong)),
+		formatRate(objective.BurnRate(slowLong)),
+	}
+}
+
+// formatPercentage formats a fraction as a percentage, or returns - if it is NaN
+func formatPercentage(fraction float64, decimals int) string {
+	if math.IsNaN(fraction) {
+		return "-"
+	}
+	return fmt.Sprintf("%.*f%%", decimals, fraction*100.0)
+}
+
+// formatRate formats a burn rate, or returns - if it is NaN
+func formatRate(rate float64) string {
+	if math.IsNaN(rate) {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fx", rate)
+}
+
+// RenderSLOsNoUI prints the service level objectives of a website without UI
+func RenderSLOsNoUI(uiView View, url string) {
+	for _, objective := range uiView.URLObjectives[url] {
+		row := objectiveRow(objective)
+		fmt.Printf("\t\tSLO %v : target=%v, actual=%v, budget remaining=%v, fast burn=%v, slow burn=%v\n", row[0], row[1], row[2], row[3], row[4], row[5])
+	}
+}
@@ -0,0 +1,212 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// cellState is the state of a column of the uptime timeline : the worst of its checks
+type cellState int
+
+// States of the columns of the uptime timeline
+const (
+	noData cellState = iota
+	up
+	slow
+	down
+)
+
+// stateColors are the colors of the states of the uptime timeline. Columns without data are grey.
+var stateColors = map[cellState]ui.Color{noData: ui.Color(8), up: ui.ColorGreen, slow: ui.ColorYellow, down: ui.ColorRed}
+
+// timelineSteps are the durations a column of the timeline can cover, the shortest fitting being used
+var timelineSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// labelWidth is the width of the labels on the left of the timeline and heatmap
+const labelWidth = 6
+
+// maxLatencyRows is the number of latency rows of the heatmap in tall terminals
+const maxLatencyRows = 10
+
+// shades are the characters of the heatmap, from the fewest samples to the most
+var shades = []rune{'░', '▒', '▓', '█'}
+
+// timeline is the checks of a window bucketed into the columns of the uptime timeline and latency heatmap
+type timeline struct {
+	// Duration covered by each column, and start of the first one
+	step  time.Duration
+	start time.Time
+	// State of each column, from the oldest
+	states []cellState
+	// Number of checks of each latency row from the fastest, and each column
+	counts [][]int
+	// Upper response time bound in ms of each latency row, the last one being the slowest check
+	bounds []int
+	// Share of successful checks
+	availability float64
+}
+
+// timelineStep returns the shortest step covering a window in at most a number of columns. It is no shorter than the
+// usual interval between checks, so that a column holds at least a check.
+func timelineStep(window time.Duration, columns int, interval time.Duration) time.Duration {
+	minimum := window / time.Duration(maxInt(columns, 1))
+	if interval > minimum {
+		minimum = interval
+	}
+	for _, step := range timelineSteps {
+		if step >= minimum {
+			return step
+		}
+	}
+	return timelineSteps[len(timelineSteps)-1]
+}
+
+// latencyBounds returns the upper bounds of latency rows on a log scale from 10 ms to the slowest response time
+func latencyBounds(rows int, slowest int) []int {
+	if slowest < 100 {
+		slowest = 100
+	}
+	bounds := make([]int, rows)
+	for row := range bounds {
+		bound := 10 * math.Pow(float64(slowest)/10, float64(row+1)/float64(rows))
+		// Rounding to 2 significant digits
+		magnitude := math.Pow(10, math.Floor(math.Log10(bound))-1)
+		bounds[row] = int(math.Ceil(bound/magnitude) * magnitude)
+	}
+	bounds[rows-1] = slowest
+	return bounds
+}
+
+// newTimeline buckets the checks of a statistic ending at a time into at most a number of columns, and their response
+// times into a number of latency rows. Checks slower than the Apdex target of the statistic are slow.
+func newTimeline(statistic *statistics.Statistic, end time.Time, columns int, rows int) timeline {
+	window := statistic.Window()
+	// Columns hold whole aggregates of the checks of long windows
+	interval := statistic.CheckInterval()
+	if statistic.AggregateStep() > interval {
+		interval = statistic.AggregateStep()
+	}
+	step := timelineStep(window, columns, interval)
+	// Aligning the columns on the step, so that they do not shift between renders
+	end = end.Truncate(step).Add(step)
+	count := int((window + step - 1) / step)
+	if count > columns {
+		count = columns
+	}
+	t := timeline{step: step, start: end.Add(-time.Duration(count) * step), states: make([]cellState, count)}
+	// The columns cover the window, whose slowest check is the slowest of the timeline
+	t.bounds = latencyBounds(maxInt(rows, 1), statistic.MaxResponseTime())
+	t.counts = make([][]int, len(t.bounds))
+	for row := range t.counts {
+		t.counts[row] = make([]int, count)
+	}
+	checks, failures := 0, 0
+	for column, bucket := range statistic.Buckets(t.start, step, count, t.bounds) {
+		switch {
+		case bucket.Failures > 0:
+			t.states[column] = down
+		case bucket.Slow > 0:
+			t.states[column] = slow
+		case bucket.Checks > 0:
+			t.states[column] = up
+		}
+		for row, rowCount := range bucket.Counts {
+			t.counts[row][column] = rowCount
+		}
+		checks += bucket.Checks
+		failures += bucket.Failures
+	}
+	if checks > 0 {
+		t.availability = float64(checks-failures) / float64(checks)
+	}
+	return t
+}
+
+// uptimeStrip is a widget drawing the state of each column of a timeline, right-aligned on the current time
+type uptimeStrip struct {
+	ui.Block
+	timeline timeline
+}
+
+// Draw draws the uptime strip, labelled with the availability over the timeline
+func (s *uptimeStrip) Draw(buf *ui.Buffer) {
+	s.Block.Draw(buf)
+	buf.SetString(fmt.Sprintf("%4.0f%%", s.timeline.availability*100), ui.NewStyle(ui.ColorWhite), s.Inner.Min)
+	x := s.Inner.Max.X - len(s.timeline.states)
+	for column, state := range s.timeline.states {
+		buf.SetCell(ui.NewCell('█', ui.NewStyle(stateColors[state])), image.Pt(x+column, s.Inner.Min.Y))
+	}
+}
+
+// latencyHeatmap is a widget drawing the number of samples of each latency row and column of a timeline, shaded
+// relatively to the busiest cell. Rows slower than the slow threshold are yellow.
+type latencyHeatmap struct {
+	ui.Block
+	timeline      timeline
+	slowThreshold int
+}
+
+// Draw draws the heatmap, the slowest row at the top, labelled with the bounds of the rows in ms
+func (h *latencyHeatmap) Draw(buf *ui.Buffer) {
+	h.Block.Draw(buf)
+	busiest := 0
+	for _, counts := range h.timeline.counts {
+		for _, count := range counts {
+			if count > busiest {
+				busiest = count
+			}
+		}
+	}
+	x := h.Inner.Max.X - len(h.timeline.states)
+	for row, bound := range h.timeline.bounds {
+		y := h.Inner.Min.Y + len(h.timeline.bounds) - 1 - row
+		if y >= h.Inner.Max.Y {
+			continue
+		}
+		buf.SetString(fmt.Sprintf("%5d", bound), ui.NewStyle(ui.ColorWhite), image.Pt(h.Inner.Min.X, y))
+		color := ui.ColorGreen
+		if bound > h.slowThreshold {
+			color = ui.ColorYellow
+		}
+		for column, count := range h.timeline.counts[row] {
+			if count == 0 {
+				continue
+			}
+			shade := shades[(count*len(shades)-1)/busiest]
+			buf.SetCell(ui.NewCell(shade, ui.NewStyle(color)), image.Pt(x+column, y))
+		}
+	}
+}
+
+// renderTimeline renders the uptime timeline and the latency heatmap of the active website over the longest timeframe
+func renderTimeline(uiView View, strip image.Rectangle, heatmap image.Rectangle) {
+	statistic := uiView.URLStatistics[uiView.Urls[uiView.ActiveWebsite]][uiView.LongestTimeframe()]
+	rows := 1
+	if !heatmap.Empty() {
+		rows = clamp(heatmap.Dy()-2, 1, maxLatencyRows)
+	}
+	t := newTimeline(statistic, time.Now(), strip.Dx()-2-labelWidth, rows)
+
+	s := &uptimeStrip{Block: *ui.NewBlock(), timeline: t}
+	s.Title = fmt.Sprintf(" Uptime, a cell per %v ", cli.FormatDuration(t.step))
+	s.SetRect(strip.Min.X, strip.Min.Y, strip.Max.X, strip.Max.Y)
+	if heatmap.Empty() {
+		// The heatmap is collapsed in short terminals
+		ui.Render(s)
+		return
+	}
+	h := &latencyHeatmap{Block: *ui.NewBlock(), timeline: t, slowThreshold: statistic.ApdexTarget()}
+	h.Title = " Response times in ms "
+	h.SetRect(heatmap.Min.X, heatmap.Min.Y, heatmap.Max.X, heatmap.Max.Y)
+	ui.Render(s, h)
+}
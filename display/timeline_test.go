@@ -0,0 +1,52 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+func TestTimelineStep(t *testing.T) {
+	// A step per minute of an hour fits in 60 columns, but not in 40
+	if step := timelineStep(time.Hour, 60, 0); step != time.Minute {
+		t.Errorf("timelineStep(1h, 60) == %v, want 1m", step)
+	}
+	if step := timelineStep(time.Hour, 40, 0); step != 2*time.Minute {
+		t.Errorf("timelineStep(1h, 40) == %v, want 2m", step)
+	}
+	// Checks every 30s have a column each rather than a column of every 10s
+	if step := timelineStep(10*time.Minute, 60, 30*time.Second); step != 30*time.Second {
+		t.Errorf("timelineStep(10m, 60) of checks every 30s == %v, want 30s", step)
+	}
+}
+
+func TestNewTimeline(t *testing.T) {
+	// The checks are aligned on the minute, as the statistic evicts them relatively to the current time
+	now := time.Now().Truncate(time.Minute)
+	statistic := statistics.NewStatistic(5*time.Minute, 500)
+	statistic.AddRecord(now.Add(-4*time.Minute), 20, 200)
+	statistic.AddRecord(now.Add(-3*time.Minute), 900, 200)
+	statistic.AddRecord(now.Add(-2*time.Minute), 30, 200)
+	statistic.AddRecord(now.Add(-2*time.Minute+30*time.Second), 5000, 408)
+	statistic.AddRecord(now, 40, 200)
+	timeline := newTimeline(statistic, now, 5, 4)
+	if timeline.step != time.Minute {
+		t.Fatalf("step == %v, want 1m", timeline.step)
+	}
+	// The columns end with the minute of the current time, the one before having no check
+	if want := []cellState{up, slow, down, noData, up}; !reflect.DeepEqual(timeline.states, want) {
+		t.Errorf("states == %v, want %v", timeline.states, want)
+	}
+	if timeline.availability != 0.8 {
+		t.Errorf("availability == %v, want 0.8", timeline.availability)
+	}
+	if len(timeline.bounds) != 4 || timeline.bounds[3] != 5000 {
+		t.Errorf("bounds == %v, want 4 bounds up to the slowest check", timeline.bounds)
+	}
+	// The fast checks are in the first row, the timeout in the last one
+	if timeline.counts[0][0] != 1 || timeline.counts[3][2] != 1 {
+		t.Errorf("counts == %v, want the fast checks first and the timeout last", timeline.counts)
+	}
+}
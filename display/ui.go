@@ -5,32 +5,132 @@ import (
 	"log"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/gizak/termui/v3"
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/contents"
+	"github.com/hugo-sv/webmonitor/incidents"
 	"github.com/hugo-sv/webmonitor/statistics"
 )
 
 // View is a Structure containing all relevant information to display the UI
 type View struct {
 	UIEnabled bool
+	// Output format without UI : text or json
+	Output string
 	// Monitored URLS
 	Urls []string
-	// Associated Statistics
-	URLStatistics map[string][3]*statistics.Statistic
+	// URLs with their secrets redacted, shown in place of them
+	Labels map[string]string
+	// Ids of the URLs, selecting them with the number keys
+	IDs map[string]int
+	// Associated Statistics, one per timeframe
+	URLStatistics map[string][]*statistics.Statistic
 	// The user's active timeframe
 	ActiveTimeframe int
-	// String representation for ActiveTimeframe
-	TimeframeRepr map[int]string
+	// Statistics timeframes
+	Timeframes []time.Duration
 	// The user's active Detailed view
 	ActiveWebsite int
+	// Service level objectives of each URL
+	URLObjectives map[string][]*statistics.Objective
+	// Incidents of each URL
+	Incidents *incidents.Tracker
+	// Whether the Detailed view shows the incidents of the active website
+	ShowIncidents bool
+	// Content watchers of the URLs detecting content changes
+	Contents map[string]*contents.Watcher
+	// Whether the Detailed view shows the last content change of the active website
+	ShowContent bool
 	// Alerts Messages
 	AlertMessages []string
+	// Called with every raised alert, such as to serve it through the API
+	OnAlert func(catalog.Alert)
 	// Alert Scroll position
 	AlertOffset int
+	// Tags of each URL, by which the websites can be filtered
+	Tags map[string]map[string]string
+	// Column of the statistics table the websites are sorted by, and whether in descending order
+	SortColumn     int
+	SortDescending bool
+	// Filter of the websites by a substring of their URL or tags, and whether it is being typed
+	Filter    string
+	Filtering bool
+	// Website list Scroll position
+	WebsiteOffset int
+	// Size of the terminal the panels are laid out in, the default size if zero
+	Width  int
+	Height int
 }
 
+// WebsiteIndex returns the index of the URL of an id, and whether there is one
+func (uiView View) WebsiteIndex(id int) (int, bool) {
+	for index, url := range uiView.Urls {
+		if uiView.IDs[url] == id {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// NextTimeframe returns the timeframe following the active one, cycling through the timeframes
+func (uiView View) NextTimeframe() int {
+	return (uiView.ActiveTimeframe + 1) % len(uiView.Timeframes)
+}
+
+// defaultTimeframe is the timeframe shown on startup, when it is one of the timeframes
+const defaultTimeframe = 10 * time.Minute
+
+// DefaultTimeframe returns the timeframe shown on startup : the 10min one, or the first one without it
+func (uiView View) DefaultTimeframe() int {
+	for id, timeframe := range uiView.Timeframes {
+		if timeframe == defaultTimeframe {
+			return id
+		}
+	}
+	return 0
+}
+
+// RefreshPeriod returns how often the stats of a timeframe are refreshed : 1/60th of the timeframe, between 10sec and 1min
+func RefreshPeriod(timeframe time.Duration) time.Duration {
+	period := timeframe / 60
+	if period < 10*time.Second {
+		return 10 * time.Second
+	}
+	if period > time.Minute {
+		return time.Minute
+	}
+	return period
+}
+
+// LongestTimeframe returns the longest of the timeframes
+func (uiView View) LongestTimeframe() int {
+	longest := 0
+	for id, timeframe := range uiView.Timeframes {
+		if timeframe > uiView.Timeframes[longest] {
+			longest = id
+		}
+	}
+	return longest
+}
+
+// statsColumnWidths are the widths of the columns of the statistics table : Id, Website, Avg, Max, p50, p90, p95,
+// p99, Avail. and Apdex. The website column takes the remaining width, and the least useful columns are dropped
+// from narrow tables.
+var statsColumnWidths = []int{4, 0, 6, 6, 5, 5, 5, 5, 7, 5}
+
+// detailColumnWidths are the widths of the columns of the detail table : Time, Avg, Max, p50, p90, p95, p99, Avail.,
+// Apdex and Codes, which takes the remaining width
+var detailColumnWidths = []int{6, 6, 6, 5, 5, 5, 5, 6, 5, 0}
+
+// droppedColumns are the columns of the statistics and detail tables dropped from narrow tables, in order : p50, p90,
+// p99, Max and Apdex
+var droppedColumns = []int{4, 5, 7, 3, 9}
+
 // Init initialize the UI
 func Init(uiView View) <-chan termui.Event {
 	if !uiView.UIEnabled {
@@ -43,6 +143,17 @@ func Init(uiView View) <-chan termui.Event {
 	return ui.PollEvents()
 }
 
+// TerminalSize returns the size of the terminal
+func TerminalSize() (int, int) {
+	return ui.TerminalDimensions()
+}
+
+// ResizeSize returns the new size of the terminal of a <Resize> event
+func ResizeSize(e termui.Event) (int, int) {
+	resize, _ := e.Payload.(termui.Resize)
+	return resize.Width, resize.Height
+}
+
 // Close Closes the UI
 func Close(uiView View) {
 	if !uiView.UIEnabled {
@@ -56,54 +167,130 @@ func RenderLayout(uiView View) {
 	if !uiView.UIEnabled {
 		return
 	}
+	ui.Clear()
+	l := uiView.layout()
+	if l.tooSmall {
+		p := widgets.NewParagraph()
+		p.Text = "The terminal is too small. Press q to quit."
+		p.TextStyle.Fg = ui.ColorYellow
+		p.SetRect(0, 0, maxInt(minWidth, 10), 3)
+		ui.Render(p)
+		return
+	}
 	p := widgets.NewParagraph()
 	p.Title = " Webmonitor "
 	p.Text = "Website monitoring tool. Press q to quit."
 	p.TextStyle.Fg = ui.ColorYellow
-	p.SetRect(0, 0, 75, 3)
+	p.SetRect(l.header.Min.X, l.header.Min.Y, l.header.Max.X, l.header.Max.Y)
 	p.BorderStyle.Fg = ui.ColorCyan
 	ui.Render(p)
 	// Render Sub-layouts
-	renderAlertsLayout()
+	renderAlertsLayout(uiView)
+	if uiView.hasObjectives() && !l.slo.Empty() {
+		renderSLOLayout(l.slo)
+	}
 	renderStatisticsLayout(uiView)
 }
 
+// RenderAll lays the whole UI out again, such as when the terminal is resized
+func RenderAll(uiView View) {
+	if !uiView.UIEnabled {
+		return
+	}
+	RenderLayout(uiView)
+	RenderStats(uiView, uiView.ActiveTimeframe)
+	RenderAlerts(uiView)
+	RenderSLOs(uiView)
+}
+
 // renderAlertsLayout renders the Alerts Layout
-func renderAlertsLayout() {
+func renderAlertsLayout(uiView View) {
 	p := widgets.NewParagraph()
 	p.Title = " Alerts "
-	p.Text = "Press up and down to scroll through alerts.\n\n There are no alerts."
+	p.Text = "Press PgUp and PgDn to scroll through alerts.\n\n There are no alerts."
 	p.TextStyle.Fg = ui.ColorYellow
-	p.SetRect(75, 0, 150, 50)
+	l := uiView.layout()
+	p.SetRect(l.alerts.Min.X, l.alerts.Min.Y, l.alerts.Max.X, l.alerts.Max.Y)
 	p.BorderStyle.Fg = ui.ColorCyan
 	ui.Render(p)
 }
 
 // renderStatisticsLayout renders the Statistics Layout
 func renderStatisticsLayout(uiView View) {
+	l := uiView.layout()
 	p1 := widgets.NewParagraph()
-	p1.Title = fmt.Sprintf(" Statistics : last %v ", uiView.TimeframeRepr[uiView.ActiveTimeframe])
-	p1.Text = fmt.Sprintf("Press s to switch to a %v timeframe.\n\n Statistics are loading ...", uiView.TimeframeRepr[3-uiView.ActiveTimeframe])
+	p1.Title = Truncate(uiView.statisticsTitle(), l.statistics.Dx()-2)
+	p1.Text = fmt.Sprintf("Press s for a %v timeframe, o or O to sort, / to filter. Times in ms.\n\n Statistics are loading ...", cli.FormatDuration(uiView.Timeframes[uiView.NextTimeframe()]))
 	p1.TextStyle.Fg = ui.ColorYellow
-	p1.SetRect(0, 3, 75, 26)
+	p1.SetRect(l.statistics.Min.X, l.statistics.Min.Y, l.statistics.Max.X, l.statistics.Max.Y)
 	p1.BorderStyle.Fg = ui.ColorCyan
 
 	p2 := widgets.NewParagraph()
-	p2.Title = fmt.Sprintf(" Details %v ", Shorten(uiView.Urls[uiView.ActiveWebsite]))
-	p2.Text = "Press a website's id to view details"
+	p2.Title = fmt.Sprintf(" Details %v ", Truncate(Shorten(uiView.Labels[uiView.Urls[uiView.ActiveWebsite]]), l.details.Dx()-12))
+	p2.Text = "Select a website with up and down to view details, i or c to toggle its incidents or content, e or E to export its samples"
 	p2.TextStyle.Fg = ui.ColorYellow
-	p2.SetRect(0, 26, 75, 50)
+	p2.SetRect(l.details.Min.X, l.details.Min.Y, l.details.Max.X, l.details.Max.Y)
 	p2.BorderStyle.Fg = ui.ColorCyan
 	ui.Render(p1, p2)
 }
 
-// renderStatTable renders a table of statistics
-func renderStatTable(Table [][]string) {
+// statisticsTitle returns the title of the Statistics panel, with the number of listed websites and the filter
+func (uiView View) statisticsTitle() string {
+	title := fmt.Sprintf(" Statistics : last %v ", cli.FormatDuration(uiView.Timeframes[uiView.ActiveTimeframe]))
+	if uiView.Filter == "" && !uiView.Filtering {
+		return title
+	}
+	count := 0
+	for _, url := range uiView.Urls {
+		if uiView.matches(url) {
+			count++
+		}
+	}
+	cursor := ""
+	if uiView.Filtering {
+		cursor = "_"
+	}
+	return fmt.Sprintf("%v- %d of %d matching /%v%v ", title, count, len(uiView.Urls), uiView.Filter, cursor)
+}
+
+// renderStatTable renders the rows of the statistics table scrolled to the active website, without the columns not
+// fitting the panel
+func renderStatTable(uiView View, rows []websiteRow) {
+	rect := uiView.layout().statsTable
+	columns, columnWidths := fitColumns(rect.Dx(), statsColumnWidths, 1, 12, droppedColumns)
+	// Marking the sort column
+	headers := append([]string(nil), statsHeaders...)
+	if uiView.SortDescending {
+		headers[uiView.SortColumn] += "▼"
+	} else {
+		headers[uiView.SortColumn] += "▲"
+	}
+	Table := [][]string{headers}
 	g := widgets.NewTable()
-	g.SetRect(0, 5, 75, 26)
+	position := 0
+	for i, row := range rows {
+		if row.index == uiView.ActiveWebsite {
+			position = i
+		}
+	}
+	capacity := uiView.websiteCapacity()
+	offset := scrollOffset(uiView.WebsiteOffset, position, capacity, len(rows))
+	for i := offset; i < len(rows) && i < offset+capacity; i++ {
+		if rows[i].index == uiView.ActiveWebsite {
+			g.RowStyles[len(Table)] = ui.NewStyle(ui.ColorBlack, ui.ColorCyan)
+		}
+		// Truncating the URLs to the website column
+		cells := append([]string(nil), rows[i].cells...)
+		cells[1] = Truncate(cells[1], columnWidths[1])
+		Table = append(Table, cells)
+	}
+	g.SetRect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
 	g.TextAlignment = ui.AlignCenter
-	g.ColumnWidths = []int{6, 34, 10, 10, 10}
-	g.Rows = Table
+	g.RowSeparator = false
+	g.FillRow = true
+	g.RowStyles[0] = ui.NewStyle(ui.ColorWhite, ui.ColorClear, ui.ModifierBold)
+	g.ColumnWidths = columnWidths
+	g.Rows = selectColumns(Table, columns)
 	ui.Render(g)
 }
 
@@ -113,9 +300,13 @@ func RenderAlerts(uiView View) {
 		RenderAlertsNoUI(uiView)
 		return
 	}
+	l := uiView.layout()
+	if l.tooSmall || len(uiView.AlertMessages) == 0 {
+		return
+	}
 	p := widgets.NewParagraph()
 	p.Text = strings.Join(uiView.AlertMessages[uiView.AlertOffset:], "\n")
-	p.SetRect(75, 2, 150, 50)
+	p.SetRect(l.alertsText.Min.X, l.alertsText.Min.Y, l.alertsText.Max.X, l.alertsText.Max.Y)
 	p.TextStyle.Fg = ui.ColorWhite
 	ui.Render(p)
 }
@@ -123,110 +314,112 @@ func RenderAlerts(uiView View) {
 // RenderStats isolate the relevant Statistics to display them.
 func RenderStats(uiView View, timeframe int) {
 	if !uiView.UIEnabled {
+		if uiView.Output == "json" {
+			renderStatsJSON(uiView, timeframe)
+			return
+		}
 		RenderStatsNoUI(uiView, timeframe)
 		return
 	}
-	if timeframe != uiView.ActiveTimeframe {
+	if timeframe != uiView.ActiveTimeframe || uiView.layout().tooSmall {
 		// The updated timeframe is not to be updated
 		return
 	}
 	renderStatisticsLayout(uiView)
 
-	// Processing the general stats table headers
-	statsHeaders := []string{
-		"Id",
-		"Website",
-		"Avg (ms)",
-		"Max (ms)",
-		"Availability",
+	// Rendering the updated table
+	renderStatTable(uiView, uiView.websiteRows())
+	if uiView.ShowIncidents {
+		renderIncidents(uiView)
+		return
 	}
-	Table := [][]string{statsHeaders}
-	// For each URL
-	var urlStatistic *statistics.Statistic
-	for id, url := range uiView.Urls {
-		urlStatistic = uiView.URLStatistics[url][uiView.ActiveTimeframe]
-		if !math.IsNaN(urlStatistic.Average()) {
-			// Append Statistics
-			Table = append(Table, []string{
-				fmt.Sprint(id),
-				Shorten(url),
-				fmt.Sprintf("%.0f", urlStatistic.Average()),
-				fmt.Sprintf("%v", urlStatistic.MaxResponseTime()),
-				fmt.Sprintf("%.0f%%", urlStatistic.Availability()*100.0),
-			})
-		}
+	if uiView.ShowContent {
+		renderContent(uiView)
+		return
 	}
-	// Rendering the updated table
-	renderStatTable(Table)
 
 	// Processing the detailed view
 	detailedStatistics := uiView.URLStatistics[uiView.Urls[uiView.ActiveWebsite]]
 	detailedHeaders := []string{
-		"TimeFrame",
-		"Avg (ms)",
-		"Max (ms)",
-		"Availability",
+		"Time",
+		"Avg",
+		"Max",
+		"p50",
+		"p90",
+		"p95",
+		"p99",
+		"Avail.",
+		"Apdex",
 		"Codes",
 	}
 	detailTable := [][]string{detailedHeaders}
 	for id, statistic := range detailedStatistics {
-		if !math.IsNaN(urlStatistic.Average()) {
+		if !math.IsNaN(statistic.Average()) {
 			// Append Statistics
 			detailTable = append(detailTable, []string{
-				uiView.TimeframeRepr[id],
+				cli.FormatDuration(uiView.Timeframes[id]),
 				fmt.Sprintf("%.0f", statistic.Average()),
 				fmt.Sprintf("%v", statistic.MaxResponseTime()),
+				fmt.Sprintf("%.0f", statistic.Percentile(50)),
+				fmt.Sprintf("%.0f", statistic.Percentile(90)),
+				fmt.Sprintf("%.0f", statistic.Percentile(95)),
+				fmt.Sprintf("%.0f", statistic.Percentile(99)),
 				fmt.Sprintf("%.0f%%", statistic.Availability()*100.0),
-				StatusCodeMapToString(statistic.StatusCodeCount),
+				fmt.Sprintf("%.2f", statistic.Apdex()),
+				StatusCodeMapToString(statistic.StatusCodeCount()),
 			})
 		}
 	}
-	// Processing the sparkline graph
-	plotValues := detailedStatistics[2].RecentResponseTime()
-
 	// Rendering the detailed view
-	renderStatDetails(uiView, detailTable, plotValues)
+	renderStatDetails(uiView, detailTable)
 }
 
 // renderStatDetails renders detailed statistics view
-func renderStatDetails(uiView View, detailTable [][]string, plotValues []float64) {
+func renderStatDetails(uiView View, detailTable [][]string) {
+	l := uiView.layout()
 	// Detailed table
+	columns, columnWidths := fitColumns(l.detailTable.Dx(), detailColumnWidths, 9, 10, droppedColumns)
 	g := widgets.NewTable()
-	g.SetRect(0, 28, 75, 37)
+	g.SetRect(l.detailTable.Min.X, l.detailTable.Min.Y, l.detailTable.Max.X, l.detailTable.Max.Y)
 	g.TextAlignment = ui.AlignCenter
-	g.ColumnWidths = []int{10, 10, 10, 10, 30}
-	g.Rows = detailTable
-
-	// Detailed sparkline
-	slc := widgets.NewSparkline()
-	slc.Data = plotValues
-	slc.LineColor = ui.ColorGreen
-
-	lc := widgets.NewSparklineGroup(slc)
-	lc.Title = " Recent Response Time "
-
-	lc.SetRect(1, 37, 75, 49)
-
-	ui.Render(g, lc)
+	g.ColumnWidths = columnWidths
+	g.Rows = selectColumns(detailTable, columns)
+	ui.Render(g)
+	if !l.timeline.Empty() {
+		// The timeline is collapsed in short terminals
+		renderTimeline(uiView, l.timeline, l.heatmap)
+	}
 }
 
 // InitNoUI Initialize without UI
 func InitNoUI(uiView View) {
+	if uiView.Output == "json" {
+		return
+	}
 	fmt.Println("Monitoring the URLS...")
 }
 
 // RenderStatsNoUI Render stats without UI
 func RenderStatsNoUI(uiView View, timeframe int) {
 	// Called at every refresh ticker,
-	fmt.Printf("Stats refreshed for %v timeframe :\n", uiView.TimeframeRepr[timeframe])
+	fmt.Printf("Stats refreshed for %v timeframe :\n", cli.FormatDuration(uiView.Timeframes[timeframe]))
 	var urlStatistic *statistics.Statistic
 	for _, url := range uiView.Urls {
 		urlStatistic = uiView.URLStatistics[url][timeframe]
-		fmt.Printf("\tWebsite : %v\n", Shorten(url))
+		fmt.Printf("\tWebsite : %v\n", Shorten(uiView.Labels[url]))
 		fmt.Printf("\t\tAverage : %.0f\n", urlStatistic.Average())
+		fmt.Printf("\t\tMin : %v\n", urlStatistic.MinResponseTime())
 		fmt.Printf("\t\tMax : %v\n", urlStatistic.MaxResponseTime())
+		fmt.Printf("\t\tPercentiles : p50=%.0f p90=%.0f p95=%.0f p99=%.0f\n",
+			urlStatistic.Percentile(50),
+			urlStatistic.Percentile(90),
+			urlStatistic.Percentile(95),
+			urlStatistic.Percentile(99),
+		)
 		fmt.Printf("\t\tAvailability : %.0f%%\n", urlStatistic.Availability()*100.0)
-		fmt.Println("\t\t" + StatusCodeMapToString(urlStatistic.StatusCodeCount))
+		fmt.Printf("\t\tApdex : %.2f\n", urlStatistic.Apdex())
+		fmt.Println("\t\t" + StatusCodeMapToString(urlStatistic.StatusCodeCount()))
+		RenderSLOsNoUI(uiView, url)
 	}
 }
 
@@ -0,0 +1,40 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultTimeframe(t *testing.T) {
+	tests := []struct {
+		timeframes []time.Duration
+		want       int
+	}{
+		{[]time.Duration{2 * time.Minute, 10 * time.Minute, time.Hour}, 1},
+		{[]time.Duration{time.Hour, 10 * time.Minute}, 1},
+		{[]time.Duration{time.Minute, 5 * time.Minute}, 0},
+	}
+	for _, test := range tests {
+		if got := (View{Timeframes: test.timeframes}).DefaultTimeframe(); got != test.want {
+			t.Errorf("DefaultTimeframe() of %v == %v, want %v", test.timeframes, got, test.want)
+		}
+	}
+}
+
+func TestRefreshPeriod(t *testing.T) {
+	tests := []struct {
+		timeframe time.Duration
+		want      time.Duration
+	}{
+		{time.Minute, 10 * time.Second},
+		{10 * time.Minute, 10 * time.Second},
+		{30 * time.Minute, 30 * time.Second},
+		{time.Hour, time.Minute},
+		{30 * 24 * time.Hour, time.Minute},
+	}
+	for _, test := range tests {
+		if got := RefreshPeriod(test.timeframe); got != test.want {
+			t.Errorf("RefreshPeriod(%v) == %v, want %v", test.timeframe, got, test.want)
+		}
+	}
+}
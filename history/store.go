@@ -0,0 +1,534 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/statistics"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket names of the store. Each of them contains a nested bucket per URL, whose keys are big-endian unix nanoseconds.
+var (
+	recordsBucket   = []byte("records")
+	rollupsBucket   = []byte("rollups")
+	incidentsBucket = []byte("incidents")
+)
+
+// flushPeriod is how often the appended records are written to the file
+const flushPeriod = time.Second
+
+// compactionPeriod is how often the retention policy is applied
+const compactionPeriod = time.Hour
+
+// yieldPeriod is how often the file is released by the monitoring process
+var yieldPeriod = 10 * time.Second
+
+// yieldDuration is how long the file is released, long enough for the processes waiting for it to lock it
+const yieldDuration = 200 * time.Millisecond
+
+// lockTimeout is how long to wait for another process to release the file, longer than the yield period
+const lockTimeout = 15 * time.Second
+
+// maxPending is the number of records kept while they cannot be written, beyond which the new ones are dropped
+var maxPending = 100000
+
+// Failure notifies that the records could not be written to the file, or could be again if Err is nil.
+// Dropped is the number of records dropped in the meantime.
+type Failure struct {
+	Err     error
+	Dropped int
+}
+
+// Record is the result of a check stored in the history
+type Record struct {
+	URL          string    `json:"-"`
+	Time         time.Time `json:"-"`
+	ResponseTime int       `json:"responseTime"`
+	StatusCode   int       `json:"statusCode"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// Rollup aggregates the records of a website over a minute
+type Rollup struct {
+	// Start of the minute
+	Time              time.Time            `json:"-"`
+	Count             int                  `json:"count"`
+	TotalResponseTime int                  `json:"totalResponseTime"`
+	MaxResponseTime   int                  `json:"maxResponseTime"`
+	MinResponseTime   int                  `json:"minResponseTime"`
+	StatusCodeCount   map[int]int          `json:"statusCodeCount"`
+	ResponseTimes     statistics.Histogram `json:"responseTimes"`
+}
+
+// Retention is the retention policy of a Store
+type Retention struct {
+	// Records older than Raw are downsampled to 1 minute rollups
+	Raw time.Duration
+	// Rollups and incidents older than Rollups are deleted
+	Rollups time.Duration
+}
+
+// DefaultRetention keeps the raw records for a day, and the rollups for 400 days
+var DefaultRetention = Retention{Raw: 24 * time.Hour, Rollups: 400 * 24 * time.Hour}
+
+// Store is a file keeping the history of every check.
+// The file stays open while monitoring, and is released for a moment every yield period, so that other processes can read it in the meantime.
+// Records and incidents are written from a goroutine, so that adding them never waits for the file.
+type Store struct {
+	path      string
+	readOnly  bool
+	retention Retention
+	// Open file, nil while it is released. Transactions hold dbMutex for reading, releasing the file holds it for writing.
+	dbMutex sync.RWMutex
+	db      *bolt.DB
+	// Error of the last attempt to open the file again, if it failed
+	openErr error
+	mutex   sync.Mutex
+	// Records and incidents waiting to be written, and the number of records dropped since the last successful write
+	pending          []Record
+	pendingIncidents []incidents.Incident
+	dropped          int
+	failing          bool
+	failures         chan Failure
+	stop             chan struct{}
+	done             chan struct{}
+}
+
+// Open opens (or creates) a history file. Appended records are written every second, and the retention policy is applied every hour.
+func Open(path string, retention Retention) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, retention: retention, db: db, failures: make(chan Failure, 16), stop: make(chan struct{}), done: make(chan struct{})}
+	err = s.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{recordsBucket, rollupsBucket, incidentsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	go s.maintain()
+	return s, nil
+}
+
+// OpenReadOnly opens an existing history file to read it, for instance while another process is monitoring.
+// The file stays locked for reading, and the monitoring process waits for it, until the Store is closed.
+func OpenReadOnly(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, readOnly: true, db: db}
+	// Checking that the file is a valid history
+	if err := s.view(func(tx *bolt.Tx) error { return nil }); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// view runs a read-only transaction on the history file
+func (s *Store) view(fn func(*bolt.Tx) error) error {
+	s.dbMutex.RLock()
+	defer s.dbMutex.RUnlock()
+	if s.db == nil {
+		return s.openErr
+	}
+	return s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(recordsBucket) == nil || tx.Bucket(rollupsBucket) == nil {
+			return fmt.Errorf("%s is not a webmonitor history file", s.path)
+		}
+		return fn(tx)
+	})
+}
+
+// update runs a read-write transaction on the history file
+func (s *Store) update(fn func(*bolt.Tx) error) error {
+	s.dbMutex.RLock()
+	defer s.dbMutex.RUnlock()
+	if s.db == nil {
+		return s.openErr
+	}
+	return s.db.Update(fn)
+}
+
+// yield releases the file for a moment, so that the processes waiting for it can read it, and opens it again once they are done.
+// Should it not be opened again, the transactions fail until the next attempt.
+func (s *Store) yield() {
+	s.dbMutex.Lock()
+	defer s.dbMutex.Unlock()
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+		time.Sleep(yieldDuration)
+	}
+	if s.db, s.openErr = bolt.Open(s.path, 0600, &bolt.Options{Timeout: lockTimeout}); s.openErr != nil {
+		s.openErr = fmt.Errorf("history file %s could not be opened again : %v", s.path, s.openErr)
+	}
+}
+
+// maintain periodically flushes the appended records and applies the retention policy, until the Store is closed
+func (s *Store) maintain() {
+	defer close(s.done)
+	flushTicker := time.NewTicker(flushPeriod)
+	defer flushTicker.Stop()
+	compactionTicker := time.NewTicker(compactionPeriod)
+	defer compactionTicker.Stop()
+	yieldTicker := time.NewTicker(yieldPeriod)
+	defer yieldTicker.Stop()
+	s.Compact(time.Now())
+	for {
+		select {
+		case <-s.stop:
+			s.Flush()
+			return
+		case <-flushTicker.C:
+			s.notify(s.Flush())
+		case <-compactionTicker.C:
+			s.Compact(time.Now())
+		case <-yieldTicker.C:
+			s.yield()
+		}
+	}
+}
+
+// Close writes the pending records, stops the maintenance of the file and closes it
+func (s *Store) Close() {
+	if !s.readOnly {
+		close(s.stop)
+		<-s.done
+	}
+	s.dbMutex.Lock()
+	defer s.dbMutex.Unlock()
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+		s.openErr = fmt.Errorf("history file %s is closed", s.path)
+	}
+}
+
+// Failures returns the channel on which the failures to write the file, and the recoveries, are sent without blocking
+func (s *Store) Failures() <-chan Failure {
+	return s.failures
+}
+
+// notify sends a failure or recovery of the writes, if it can be received, when the writes start or stop failing
+func (s *Store) notify(err error) {
+	s.mutex.Lock()
+	if (err != nil) == s.failing {
+		s.mutex.Unlock()
+		return
+	}
+	s.failing = err != nil
+	failure := Failure{Err: err, Dropped: s.dropped}
+	if err == nil {
+		s.dropped = 0
+	}
+	s.mutex.Unlock()
+	select {
+	case s.failures <- failure:
+	default:
+	}
+}
+
+// Append adds a record to the history, without blocking. It is written to the file within a second.
+// While the file cannot be written, up to maxPending records are kept, and the next ones are dropped.
+func (s *Store) Append(r Record) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.pending) >= maxPending {
+		s.dropped++
+		return
+	}
+	s.pending = append(s.pending, r)
+}
+
+// Flush writes the pending records and incidents to the file
+func (s *Store) Flush() error {
+	s.mutex.Lock()
+	pending, pendingIncidents := s.pending, s.pendingIncidents
+	s.pending, s.pendingIncidents = nil, nil
+	s.mutex.Unlock()
+	if len(pending) == 0 && len(pendingIncidents) == 0 {
+		return nil
+	}
+	err := s.update(func(tx *bolt.Tx) error {
+		for _, incident := range pendingIncidents {
+			bucket, err := tx.Bucket(incidentsBucket).CreateBucketIfNotExists([]byte(incident.URL))
+			if err != nil {
+				return err
+			}
+			value, _ := json.Marshal(incident)
+			if err := bucket.Put(timeKey(incident.Start), value); err != nil {
+				return err
+			}
+		}
+		for _, r := range pending {
+			bucket, err := tx.Bucket(recordsBucket).CreateBucketIfNotExists([]byte(r.URL))
+			if err != nil {
+				return err
+			}
+			value, _ := json.Marshal(r)
+			if err := bucket.Put(timeKey(r.Time), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Keeping the records and incidents for the next flush, the most recent records being dropped beyond maxPending
+		s.mutex.Lock()
+		s.pending = append(pending, s.pending...)
+		if len(s.pending) > maxPending {
+			s.dropped += len(s.pending) - maxPending
+			s.pending = s.pending[:maxPending]
+		}
+		s.pendingIncidents = append(pendingIncidents, s.pendingIncidents...)
+		s.mutex.Unlock()
+	}
+	return err
+}
+
+// URLs returns the URLs having a history
+func (s *Store) URLs() ([]string, error) {
+	urls := make([]string, 0)
+	seen := make(map[string]bool)
+	err := s.view(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{recordsBucket, rollupsBucket} {
+			tx.Bucket(name).ForEach(func(url []byte, _ []byte) error {
+				if !seen[string(url)] {
+					seen[string(url)] = true
+					urls = append(urls, string(url))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return urls, err
+}
+
+// Records returns the raw records of a website checked within [from, to), from the oldest to the most recent
+func (s *Store) Records(url string, from time.Time, to time.Time) ([]Record, error) {
+	records := make([]Record, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEachBetween(tx.Bucket(recordsBucket).Bucket([]byte(url)), from, to, func(t time.Time, value []byte) error {
+			r := Record{URL: url, Time: t}
+			if err := json.Unmarshal(value, &r); err != nil {
+				return err
+			}
+			records = append(records, r)
+			return nil
+		})
+	})
+	return records, err
+}
+
+// Rollups returns the rollups of a website whose minute starts within [from, to), from the oldest to the most recent
+func (s *Store) Rollups(url string, from time.Time, to time.Time) ([]Rollup, error) {
+	rollups := make([]Rollup, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		return forEachBetween(tx.Bucket(rollupsBucket).Bucket([]byte(url)), from, to, func(t time.Time, value []byte) error {
+			r := Rollup{Time: t}
+			if err := json.Unmarshal(value, &r); err != nil {
+				return err
+			}
+			rollups = append(rollups, r)
+			return nil
+		})
+	})
+	return rollups, err
+}
+
+// SaveIncident records an incident, or updates it if it was already recorded, without blocking. It is written to the file
+// within a second, along with the records. Incidents are few, they are kept until they are written.
+func (s *Store) SaveIncident(incident incidents.Incident) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pendingIncidents = append(s.pendingIncidents, incident)
+}
+
+// Incidents returns the incidents of a website which started within [from, to), from the oldest to the most recent
+func (s *Store) Incidents(url string, from time.Time, to time.Time) ([]incidents.Incident, error) {
+	urlIncidents := make([]incidents.Incident, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(incidentsBucket)
+		if bucket == nil {
+			// History file recorded before incidents were tracked
+			return nil
+		}
+		return forEachBetween(bucket.Bucket([]byte(url)), from, to, func(t time.Time, value []byte) error {
+			var incident incidents.Incident
+			if err := json.Unmarshal(value, &incident); err != nil {
+				return err
+			}
+			urlIncidents = append(urlIncidents, incident)
+			return nil
+		})
+	})
+	return urlIncidents, err
+}
+
+// LastIncident returns the most recent incident of a website which started before a time, and whether there is one
+func (s *Store) LastIncident(url string, before time.Time) (incidents.Incident, bool, error) {
+	var incident incidents.Incident
+	found := false
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(incidentsBucket)
+		if bucket == nil || bucket.Bucket([]byte(url)) == nil {
+			return nil
+		}
+		c := bucket.Bucket([]byte(url)).Cursor()
+		k, value := c.Seek(timeKey(before))
+		if k == nil {
+			k, value = c.Last()
+		} else {
+			k, value = c.Prev()
+		}
+		if k == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(value, &incident)
+	})
+	return incident, found, err
+}
+
+// Compact applies the retention policy : old records are downsampled to 1 minute rollups, and old rollups and incidents are deleted
+func (s *Store) Compact(now time.Time) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	rawLimit := now.Add(-s.retention.Raw).Truncate(time.Minute)
+	rollupLimit := now.Add(-s.retention.Rollups)
+	return s.update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(recordsBucket)
+		rollups := tx.Bucket(rollupsBucket)
+		urls := make([][]byte, 0)
+		records.ForEach(func(url []byte, _ []byte) error {
+			urls = append(urls, url)
+			return nil
+		})
+		for _, url := range urls {
+			urlRollups, err := rollups.CreateBucketIfNotExists(url)
+			if err != nil {
+				return err
+			}
+			if err := downsample(records.Bucket(url), urlRollups, rawLimit); err != nil {
+				return err
+			}
+		}
+		// Deleting the outdated rollups and incidents
+		for _, bucket := range []*bolt.Bucket{rollups, tx.Bucket(incidentsBucket)} {
+			err := bucket.ForEach(func(url []byte, _ []byte) error {
+				return deleteBefore(bucket.Bucket(url), rollupLimit)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// downsample aggregates the records older than limit into rollups, and deletes them
+func downsample(records *bolt.Bucket, rollups *bolt.Bucket, limit time.Time) error {
+	aggregated := make(map[time.Time]*Rollup)
+	err := forEachBetween(records, time.Time{}, limit, func(t time.Time, value []byte) error {
+		var r Record
+		if err := json.Unmarshal(value, &r); err != nil {
+			return err
+		}
+		minute := t.Truncate(time.Minute)
+		rollup, ok := aggregated[minute]
+		if !ok {
+			// A previous compaction may have already started this rollup
+			rollup = &Rollup{Time: minute}
+			if existing := rollups.Get(timeKey(minute)); existing != nil {
+				json.Unmarshal(existing, rollup)
+			}
+			aggregated[minute] = rollup
+		}
+		rollup.add(r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for minute, rollup := range aggregated {
+		value, _ := json.Marshal(rollup)
+		if err := rollups.Put(timeKey(minute), value); err != nil {
+			return err
+		}
+	}
+	return deleteBefore(records, limit)
+}
+
+// add aggregates a record into a Rollup
+func (r *Rollup) add(record Record) {
+	if r.StatusCodeCount == nil {
+		r.StatusCodeCount = make(map[int]int)
+	}
+	if r.Count == 0 || record.ResponseTime < r.MinResponseTime {
+		r.MinResponseTime = record.ResponseTime
+	}
+	if record.ResponseTime > r.MaxResponseTime {
+		r.MaxResponseTime = record.ResponseTime
+	}
+	r.Count++
+	r.TotalResponseTime += record.ResponseTime
+	r.StatusCodeCount[record.StatusCode]++
+	r.ResponseTimes.Record(record.ResponseTime)
+}
+
+// forEachBetween calls fn for each key of a bucket whose time is within [from, to)
+func forEachBetween(bucket *bolt.Bucket, from time.Time, to time.Time, fn func(time.Time, []byte) error) error {
+	if bucket == nil {
+		return nil
+	}
+	c := bucket.Cursor()
+	end := timeKey(to)
+	for k, v := c.Seek(timeKey(from)); k != nil && string(k) < string(end); k, v = c.Next() {
+		if err := fn(keyTime(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteBefore deletes the keys of a bucket whose time is before limit
+func deleteBefore(bucket *bolt.Bucket, limit time.Time) error {
+	c := bucket.Cursor()
+	end := timeKey(limit)
+	for k, _ := c.First(); k != nil && string(k) < string(end); k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timeKey encodes a time as a sortable key
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	if !t.IsZero() {
+		binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	}
+	return key
+}
+
+// keyTime decodes a key encoded with timeKey
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)))
+}
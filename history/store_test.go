@@ -0,0 +1,143 @@
+package history
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/incidents"
+)
+
+func TestStoreCompact(t *testing.T) {
+	// Test if old records are downsampled to rollups, and outdated rollups deleted
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	defer os.RemoveAll(dir)
+	store, err := Open(filepath.Join(dir, "history.db"), Retention{Raw: time.Hour, Rollups: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Open returned %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().Truncate(time.Minute)
+	url := "https://example.com"
+	for _, r := range []Record{
+		// Outdated
+		{URL: url, Time: now.Add(-48 * time.Hour), ResponseTime: 100, StatusCode: 200},
+		// Downsampled in the same rollup
+		{URL: url, Time: now.Add(-2 * time.Hour), ResponseTime: 100, StatusCode: 200},
+		{URL: url, Time: now.Add(-2*time.Hour + 30*time.Second), ResponseTime: 300, StatusCode: 500},
+		// Kept raw
+		{URL: url, Time: now.Add(-time.Minute), ResponseTime: 50, StatusCode: 200},
+	} {
+		store.Append(r)
+	}
+	if err := store.Compact(now); err != nil {
+		t.Fatalf("Compact returned %v", err)
+	}
+
+	records, _ := store.Records(url, time.Time{}, now)
+	if len(records) != 1 || records[0].ResponseTime != 50 {
+		t.Errorf("Records() == %v, want the last record only", records)
+	}
+	rollups, _ := store.Rollups(url, time.Time{}, now)
+	if len(rollups) != 1 {
+		t.Fatalf("Rollups() returned %v rollups, want 1", len(rollups))
+	}
+	rollup := rollups[0]
+	if rollup.Count != 2 || rollup.MaxResponseTime != 300 || rollup.StatusCodeCount[500] != 1 || rollup.ResponseTimes.Count() != 2 {
+		t.Errorf("Rollups()[0] == %+v, want the aggregate of the 2 hours old records", rollup)
+	}
+	if !rollup.Time.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("Rollups()[0].Time == %v, want %v", rollup.Time, now.Add(-2*time.Hour))
+	}
+}
+
+func TestStoreReadWhileOpen(t *testing.T) {
+	// Test if another process can read the file while it is open for monitoring
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	defer os.RemoveAll(dir)
+	defer func(period time.Duration) { yieldPeriod = period }(yieldPeriod)
+	yieldPeriod = 100 * time.Millisecond
+	path := filepath.Join(dir, "history.db")
+	store, err := Open(path, DefaultRetention)
+	if err != nil {
+		t.Fatalf("Open returned %v", err)
+	}
+	defer store.Close()
+	now := time.Now()
+	url := "https://example.com"
+	store.Append(Record{URL: url, Time: now.Add(-time.Minute), ResponseTime: 100, StatusCode: 200})
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush returned %v", err)
+	}
+
+	// The reader waits for the file to be released
+	reader, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly returned %v", err)
+	}
+	records, err := reader.Records(url, time.Time{}, now)
+	reader.Close()
+	if err != nil || len(records) != 1 {
+		t.Errorf("Records() == %v, %v, want the flushed record", records, err)
+	}
+
+	// The file is then opened again for monitoring, the transactions waiting for it
+	store.Append(Record{URL: url, Time: now, ResponseTime: 100, StatusCode: 200})
+	if err := store.Flush(); err != nil {
+		t.Errorf("Flush returned %v after the file was read", err)
+	}
+	if records, _ := store.Records(url, time.Time{}, now.Add(time.Second)); len(records) != 2 {
+		t.Errorf("Records() == %v, want the 2 records", records)
+	}
+}
+
+func TestStorePending(t *testing.T) {
+	// Test if the records are kept while the file cannot be written, up to maxPending
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	defer os.RemoveAll(dir)
+	defer func(limit int) { maxPending = limit }(maxPending)
+	maxPending = 3
+	store, err := Open(filepath.Join(dir, "history.db"), DefaultRetention)
+	if err != nil {
+		t.Fatalf("Open returned %v", err)
+	}
+	defer store.Close()
+	// Releasing the file as if it could not be opened again
+	store.dbMutex.Lock()
+	store.db.Close()
+	store.db, store.openErr = nil, errors.New("file locked")
+	store.dbMutex.Unlock()
+
+	now := time.Now()
+	url := "https://example.com"
+	store.SaveIncident(incidents.Incident{URL: url, Start: now.Add(-time.Minute), End: now})
+	for i := 5; i > 0; i-- {
+		store.Append(Record{URL: url, Time: now.Add(-time.Duration(i) * time.Second), ResponseTime: 100, StatusCode: 200})
+	}
+	err = store.Flush()
+	if err == nil {
+		t.Fatalf("Flush returned no error without a file")
+	}
+	store.notify(err)
+	if failure := <-store.Failures(); failure.Err == nil {
+		t.Errorf("Failures() sent %v, want the error", failure)
+	}
+
+	// Once the file is opened again, the kept records and the incident are written
+	store.yield()
+	err = store.Flush()
+	store.notify(err)
+	if failure := <-store.Failures(); err != nil || failure.Err != nil || failure.Dropped != 2 {
+		t.Errorf("Failures() sent %v after Flush returned %v, want a recovery with 2 dropped records", failure, err)
+	}
+	if records, _ := store.Records(url, time.Time{}, now); len(records) != 3 || !records[0].Time.Equal(now.Add(-5*time.Second)) {
+		t.Errorf("Records() == %v, want the 3 oldest records", records)
+	}
+	if urlIncidents, _ := store.Incidents(url, time.Time{}, now); len(urlIncidents) != 1 {
+		t.Errorf("Incidents() == %v, want the saved incident", urlIncidents)
+	}
+}
@@ -0,0 +1,158 @@
+package incidents
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sample is the result of a check
+type Sample struct {
+	Time         time.Time `json:"time"`
+	ResponseTime int       `json:"responseTime"`
+	StatusCode   int       `json:"statusCode"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// Incident is a contiguous period during which a website is down
+type Incident struct {
+	URL   string    `json:"url"`
+	Start time.Time `json:"start"`
+	// End of the incident, zero while it is ongoing
+	End time.Time `json:"end"`
+	// Reason of the first failed check
+	FirstFailure string `json:"firstFailure"`
+	// Checks of the window that triggered the incident
+	Samples []Sample `json:"samples"`
+}
+
+// Ongoing returns whether an Incident is still open
+func (i Incident) Ongoing() bool {
+	return i.End.IsZero()
+}
+
+// Duration returns the duration of an Incident, up to now if it is ongoing
+func (i Incident) Duration(now time.Time) time.Duration {
+	if i.Ongoing() {
+		return now.Sub(i.Start)
+	}
+	return i.End.Sub(i.Start)
+}
+
+// Interrupt closes an Incident whose end was not seen, as the monitor stopped while it was ongoing.
+// It ends at the last check of the website, or at the last check of the Incident if it is more recent.
+func (i *Incident) Interrupt(lastCheck time.Time) {
+	i.End = i.Start
+	if lastCheck.After(i.End) {
+		i.End = lastCheck
+	}
+	for _, sample := range i.Samples {
+		if sample.Time.After(i.End) {
+			i.End = sample.Time
+		}
+	}
+}
+
+// Tracker keeps track of the incidents of the websites, and of their recent checks
+type Tracker struct {
+	mutex sync.Mutex
+	// Duration of the recent checks kept to describe a new incident
+	window    time.Duration
+	recent    map[string][]Sample
+	incidents map[string][]*Incident
+}
+
+// NewTracker returns a new Tracker, describing new incidents with the checks of the last window of time
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{window: window, recent: make(map[string][]Sample), incidents: make(map[string][]*Incident)}
+}
+
+// Record adds the result of a check to the recent checks of a website
+func (t *Tracker) Record(url string, sample Sample) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	recent := append(t.recent[url], sample)
+	limit := sample.Time.Add(-t.window)
+	for len(recent) > 0 && !recent[0].Time.After(limit) {
+		recent = recent[1:]
+	}
+	t.recent[url] = recent
+}
+
+// Open opens an incident for a website, unless one is ongoing. It starts at the first failed check of the recent checks.
+// It returns the opened incident, and whether it was opened.
+func (t *Tracker) Open(url string, at time.Time) (Incident, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if ongoing := t.ongoing(url); ongoing != nil {
+		return *ongoing, false
+	}
+	incident := &Incident{URL: url, Start: at, Samples: make([]Sample, 0)}
+	for _, sample := range t.recent[url] {
+		if sample.StatusCode != 200 && incident.FirstFailure == "" {
+			incident.Start = sample.Time
+			incident.FirstFailure = sample.Reason
+		}
+		incident.Samples = append(incident.Samples, sample)
+	}
+	t.incidents[url] = append(t.incidents[url], incident)
+	return *incident, true
+}
+
+// Close closes the ongoing incident of a website. It returns the closed incident, and whether there was one.
+func (t *Tracker) Close(url string, at time.Time) (Incident, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	ongoing := t.ongoing(url)
+	if ongoing == nil {
+		return Incident{}, false
+	}
+	ongoing.End = at
+	return *ongoing, true
+}
+
+// ongoing returns the ongoing incident of a website, or nil
+func (t *Tracker) ongoing(url string) *Incident {
+	incidents := t.incidents[url]
+	if len(incidents) > 0 && incidents[len(incidents)-1].Ongoing() {
+		return incidents[len(incidents)-1]
+	}
+	return nil
+}
+
+// Restore adds previously recorded incidents, such as those read from the history.
+// Incidents still ongoing when the monitor stopped should be interrupted first, or they would prevent new ones from being opened.
+// Every restored incident is kept in memory : only the recent ones should be restored.
+func (t *Tracker) Restore(incidents []Incident) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i := range incidents {
+		incident := incidents[i]
+		t.incidents[incident.URL] = append(t.incidents[incident.URL], &incident)
+	}
+	for url := range t.incidents {
+		urlIncidents := t.incidents[url]
+		sort.Slice(urlIncidents, func(i, j int) bool {
+			return urlIncidents[i].Start.Before(urlIncidents[j].Start)
+		})
+	}
+}
+
+// Remove forgets the incidents and recent checks of a website
+func (t *Tracker) Remove(url string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.recent, url)
+	delete(t.incidents, url)
+}
+
+// Incidents returns the incidents of a website, from the most recent to the oldest
+func (t *Tracker) Incidents(url string) []Incident {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	incidents := make([]Incident, 0, len(t.incidents[url]))
+	for index := len(t.incidents[url]) - 1; index >= 0; index-- {
+		incidents = append(incidents, *t.incidents[url][index])
+	}
+	return incidents
+}
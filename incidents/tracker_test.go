@@ -0,0 +1,74 @@
+package incidents
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(2 * time.Minute)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	url := "https://example.com"
+	tracker.Record(url, Sample{Time: start, ResponseTime: 100, StatusCode: 200})
+	tracker.Record(url, Sample{Time: start.Add(3 * time.Minute), ResponseTime: 100, StatusCode: 200})
+	tracker.Record(url, Sample{Time: start.Add(4 * time.Minute), ResponseTime: 5000, StatusCode: 408, Reason: "timeout"})
+	tracker.Record(url, Sample{Time: start.Add(5 * time.Minute), ResponseTime: 10, StatusCode: 503, Reason: "503 Service Unavailable"})
+
+	incident, opened := tracker.Open(url, start.Add(5*time.Minute))
+	if !opened {
+		t.Fatalf("Open() did not open an incident")
+	}
+	if !incident.Start.Equal(start.Add(4*time.Minute)) || incident.FirstFailure != "timeout" {
+		t.Errorf("Open() started at %v because of %q, want %v because of %q", incident.Start, incident.FirstFailure, start.Add(4*time.Minute), "timeout")
+	}
+	// The successful checks are out of the window
+	if len(incident.Samples) != 2 {
+		t.Errorf("Open() kept %d samples, want 2", len(incident.Samples))
+	}
+	if _, opened := tracker.Open(url, start.Add(6*time.Minute)); opened {
+		t.Errorf("Open() opened a second incident while one is ongoing")
+	}
+
+	incident, closed := tracker.Close(url, start.Add(10*time.Minute))
+	if !closed || incident.Ongoing() || incident.Duration(time.Time{}) != 6*time.Minute {
+		t.Errorf("Close() returned %v, %v, want a closed incident of 6m0s", incident, closed)
+	}
+	if _, closed := tracker.Close(url, start.Add(11*time.Minute)); closed {
+		t.Errorf("Close() closed an incident twice")
+	}
+
+	tracker.Restore([]Incident{{URL: url, Start: start.Add(-time.Hour), End: start.Add(-30 * time.Minute)}})
+	incidents := tracker.Incidents(url)
+	if len(incidents) != 2 || !incidents[0].Start.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("Incidents() returned %v, want the 2 incidents from the most recent", incidents)
+	}
+}
+
+func TestIncidentInterrupt(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []Sample{{Time: start, StatusCode: 500}, {Time: start.Add(time.Minute), StatusCode: 500}}
+	tests := []struct {
+		lastCheck time.Time
+		want      time.Time
+	}{
+		{start.Add(time.Hour), start.Add(time.Hour)},
+		// Without more recent checks, at the last check of the incident
+		{start.Add(30 * time.Second), start.Add(time.Minute)},
+		{time.Time{}, start.Add(time.Minute)},
+	}
+	for _, test := range tests {
+		incident := Incident{Start: start, Samples: samples}
+		incident.Interrupt(test.lastCheck)
+		if incident.Ongoing() || !incident.End.Equal(test.want) {
+			t.Errorf("Interrupt(%v) ended the incident at %v, want %v", test.lastCheck, incident.End, test.want)
+		}
+	}
+	// An interrupted incident no longer prevents a new one from being opened
+	tracker := NewTracker(2 * time.Minute)
+	incident := Incident{URL: "https://example.com", Start: start, Samples: samples}
+	incident.Interrupt(start.Add(time.Hour))
+	tracker.Restore([]Incident{incident})
+	if _, opened := tracker.Open("https://example.com", start.Add(2*time.Hour)); !opened {
+		t.Errorf("Open() did not open an incident after an interrupted one")
+	}
+}
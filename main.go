@@ -2,58 +2,427 @@ package main
 
 import (
 	"fmt"
-	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/hugo-sv/webmonitor/api"
+	"github.com/hugo-sv/webmonitor/catalog"
 	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/dashboard"
 	"github.com/hugo-sv/webmonitor/display"
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/metrics"
 	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/otlp"
+	"github.com/hugo-sv/webmonitor/report"
 	"github.com/hugo-sv/webmonitor/statistics"
+	"github.com/hugo-sv/webmonitor/statuspage"
+	"github.com/hugo-sv/webmonitor/timeseries"
 )
 
+// alertTimeframe is the timeframe over which the availability alerts are computed
+const alertTimeframe = 2 * time.Minute
+
+// serve serves HTTP requests on an address in a goroutine, and returns the server
+func serve(addr string, handler http.Handler) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	return server, nil
+}
+
+// newSeriesExporter returns a started exporter to a time series database, named after its type and destination
+func newSeriesExporter(config cli.Exporter, failures chan<- timeseries.Failure) *timeseries.Exporter {
+	var backend timeseries.Backend
+	destination := config.Address
+	switch {
+	case config.Type == "influxdb" && config.URL != "":
+		backend = timeseries.NewInfluxHTTP(config.URL, config.Authorization)
+		destination = config.URL
+	case config.Type == "influxdb":
+		backend = timeseries.NewInfluxFile(config.Path)
+		destination = config.Path
+	case config.Type == "statsd":
+		backend = timeseries.NewStatsD(config.Address, config.Prefix)
+	case config.Type == "otlp":
+		backend = otlp.NewMetrics(otlp.NewClient(config.URL, config.Headers))
+		destination = config.URL
+	default:
+		backend = timeseries.NewGraphite(config.Address, config.Prefix)
+	}
+	return timeseries.NewExporter(cli.Redact(config.Type+" "+destination), backend, config.BatchSize, config.BufferSize, config.FlushIntervalDuration, failures)
+}
+
+// runReport prints the availability report of the websites recorded in a history file
+func runReport(args []string) {
+	reportConfig, ok := cli.ParseReportFlags(args)
+	if !ok {
+		os.Exit(2)
+	}
+	store, err := history.OpenReadOnly(reportConfig.HistoryPath)
+	if err == nil {
+		var summaries []report.Summary
+		summaries, err = report.Build(store, reportConfig.Urls, reportConfig.From, reportConfig.To, reportConfig.ApdexTargets, reportConfig.ApdexTarget)
+		store.Close()
+		if err == nil {
+			err = report.Write(os.Stdout, reportConfig.Format, summaries)
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// runExport writes the samples of a website recorded in a history file
+func runExport(args []string) {
+	exportConfig, ok := cli.ParseExportFlags(args)
+	if !ok {
+		os.Exit(2)
+	}
+	store, err := history.OpenReadOnly(exportConfig.HistoryPath)
+	if err == nil {
+		var records []history.Record
+		records, err = exportedRecords(store, exportConfig.URL, exportConfig.From, exportConfig.To)
+		store.Close()
+		if err == nil {
+			err = writeSamples(exportConfig.OutPath, exportConfig.Format, exportConfig.URL, report.RecordSamples(records))
+		}
+	}
+	if err != nil {
+		fmt.Println(cli.Redact(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// exportedRecords returns the raw records of a website over [from, to). It fails if part of the period was downsampled to
+// rollups, whose checks can no longer be exported, rather than exporting a period missing them.
+func exportedRecords(store *history.Store, url string, from time.Time, to time.Time) ([]history.Record, error) {
+	rollups, err := store.Rollups(url, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(rollups) > 0 {
+		downsampled := rollups[len(rollups)-1].Time.Add(time.Minute)
+		return nil, fmt.Errorf("checks before %s were downsampled to 1 minute rollups and cannot be exported : start the period at that time or later", downsampled.Format(time.RFC3339))
+	}
+	return store.Records(url, from, to)
+}
+
+// typeFilter edits the filter of the websites with a key : Enter keeps it, Escape clears it, Backspace erases its last
+// character and other keys are typed in. The first matching website is selected if the active one no longer matches.
+func typeFilter(uiView *display.View, key string) {
+	switch key {
+	case "<Enter>":
+		uiView.Filtering = false
+	case "<Escape>":
+		uiView.Filtering = false
+		uiView.Filter = ""
+	case "<Backspace>", "<C-<Backspace>>":
+		if filter := []rune(uiView.Filter); len(filter) > 0 {
+			uiView.Filter = string(filter[:len(filter)-1])
+		}
+	case "<Space>":
+		uiView.Filter += " "
+	default:
+		if len([]rune(key)) == 1 {
+			uiView.Filter += key
+		}
+	}
+	uiView.MoveSelection(0)
+}
+
+// exportSamples writes the samples of the active website over the active timeframe to a file of the working
+// directory, and returns the message telling where
+func exportSamples(uiView display.View, format string) string {
+	url := uiView.Urls[uiView.ActiveWebsite]
+	samples := uiView.URLStatistics[url][uiView.ActiveTimeframe].Samples()
+	path := fmt.Sprintf("webmonitor-%d-%s.%s", uiView.IDs[url], time.Now().Format("20060102-150405"), format)
+	if err := writeSamples(path, format, uiView.Labels[url], samples); err != nil {
+		return fmt.Sprintf("Samples of %s could not be exported : %v", display.Shorten(uiView.Labels[url]), err)
+	}
+	return fmt.Sprintf("Exported %d samples of %s to %s", len(samples), display.Shorten(uiView.Labels[url]), path)
+}
+
+// writeSamples writes the samples of a website to a file, or to the standard output if the path is empty
+func writeSamples(path string, format string, url string, samples []statistics.Sample) error {
+	if path == "" {
+		return report.WriteSamples(os.Stdout, format, url, samples)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = report.WriteSamples(file, format, url, samples); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runStatusPage writes the status page of the websites recorded in a history file, or serves it
+func runStatusPage(args []string) {
+	statusConfig, ok := cli.ParseStatusFlags(args)
+	if !ok {
+		os.Exit(2)
+	}
+	store, err := history.OpenReadOnly(statusConfig.HistoryPath)
+	if err == nil && statusConfig.Addr != "" {
+		// The server opens the history file each time it builds the page, rather than keeping it locked
+		store.Close()
+		fmt.Printf("Serving the status page on %s\n", statusConfig.Addr)
+		err = http.ListenAndServe(statusConfig.Addr, statuspage.NewServer(statusConfig.HistoryPath, statusConfig.Page, statusConfig.Days))
+	} else if err == nil {
+		var page statuspage.Page
+		if page, err = statuspage.Build(store, statusConfig.Page, statusConfig.Days, time.Now()); err == nil {
+			err = statuspage.Generate(statusConfig.OutDir, page)
+		}
+		store.Close()
+	}
+	if err != nil {
+		fmt.Println(cli.Redact(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// runManage manages the websites of a running webmonitor through its API
+func runManage(command string, args []string) {
+	manageConfig, ok := cli.ParseManageFlags(command, args)
+	if !ok {
+		os.Exit(2)
+	}
+	client := api.NewClient(manageConfig.APIAddr, manageConfig.APIToken)
+	var err error
+	switch command {
+	case "list":
+		var websites []api.Website
+		if websites, err = client.Websites(); err == nil {
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "ID\tURL\tINTERVAL\tPAUSED")
+			for _, website := range websites {
+				fmt.Fprintf(writer, "%d\t%s\t%ds\t%v\n", website.ID, website.URL, website.Interval, website.Paused)
+			}
+			writer.Flush()
+		}
+	case "add":
+		var website api.Website
+		if website, err = client.Add(manageConfig.Website); err == nil {
+			fmt.Printf("Website %s added with id %d\n", website.URL, website.ID)
+		}
+	default:
+		var id int
+		if id, err = resolveWebsite(client, manageConfig.Target); err != nil {
+			break
+		}
+		switch command {
+		case "pause":
+			_, err = client.Pause(id)
+		case "resume":
+			_, err = client.Resume(id)
+		case "check":
+			_, err = client.Check(id)
+		case "remove":
+			err = client.Remove(id)
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// resolveWebsite returns the id of a website given by its id, or by its URL as listed by the API. The secrets of the
+// listed URLs are redacted by the running instance, so such a URL is given redacted as well, or by its id.
+func resolveWebsite(client *api.Client, target string) (int, error) {
+	if id, err := strconv.Atoi(target); err == nil {
+		return id, nil
+	}
+	websites, err := client.Websites()
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]int, 0, 1)
+	for _, website := range websites {
+		if website.URL == target {
+			ids = append(ids, website.ID)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return 0, fmt.Errorf("website %s is not monitored, give its id or its URL as listed by webmonitor list", target)
+	case 1:
+		return ids[0], nil
+	}
+	return 0, fmt.Errorf("websites %v are all listed as %s, give the id of one of them", ids, target)
+}
+
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		runReport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		runExport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "status-page" {
+		runStatusPage(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && cli.ManageCommands[os.Args[1]] {
+		runManage(os.Args[1], os.Args[2:])
+		return
+	}
 	// Retrieving the cli command's flags
-	timeout, urls, intervals, uiEnabled := cli.ParseFlags()
-	if len(urls) == 0 {
+	config := cli.ParseFlags()
+	if len(config.Urls) == 0 {
 		// There are no URL to track
 		return
 	}
 	// Channel messages
-	stop := make(chan struct{}, len(urls))
 	statsMessage := make(chan monitor.CheckStats)
-	// Setting up the Statistics system
-	urlStatistics := make(map[string][3]*statistics.Statistic)
-	var checkInterval int
-	for _, url := range urls {
-		checkInterval = intervals[url]
-		// Keeping track of enough records for 2min, 10min and 1h timeframes
-		urlStatistics[url] = [3]*statistics.Statistic{
-			statistics.NewStatistic(int(math.Ceil(float64(2*60) / float64(checkInterval)))),
-			statistics.NewStatistic(int(math.Ceil(float64(10*60) / float64(checkInterval)))),
-			statistics.NewStatistic(int(math.Ceil(float64(60*60) / float64(checkInterval)))),
-		}
-		// Starting a goroutine fetching data for this URL
-		go monitor.CheckOnTicks(url, checkInterval, timeout, stop, statsMessage)
-	}
-	// These Display tickers will refresh the stats display every 10sec and 1min for the past 10min and 1h respectively
-	displayTicker1 := time.NewTicker(time.Second * time.Duration(10))
-	defer displayTicker1.Stop()
-	displayTicker2 := time.NewTicker(time.Second * time.Duration(60))
-	defer displayTicker2.Stop()
+	burning := make(map[burnAlert]bool)
+	// Incidents are described with the checks of the alerts timeframe
+	tracker := incidents.NewTracker(alertTimeframe)
+	// Recording the checks in the history file, and rebuilding the statistics from it. Its write failures are received on the historyFailures channel.
+	var store *history.Store
+	var historyFailures <-chan history.Failure
+	if config.HistoryPath != "" {
+		var err error
+		if store, err = history.Open(config.HistoryPath, config.HistoryRetention); err != nil {
+			fmt.Println(cli.Redact(err.Error()))
+			return
+		}
+		defer store.Close()
+		historyFailures = store.Failures()
+	}
+	// Serving the Prometheus metrics
+	var exporter *metrics.Exporter
+	if config.MetricsAddr != "" {
+		exporter = metrics.NewExporter(config.Timeframes)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		server, err := serve(config.MetricsAddr, mux)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer server.Close()
+	}
+	// Serving the API, whose management requests are received on the commands channel
+	var apiServer *api.Server
+	var commands <-chan api.Command
+	if config.APIAddr != "" {
+		apiServer = api.NewServer(config.Timeframes, tracker, config.APIToken)
+		commands = apiServer.Commands()
+		server, err := serve(config.APIAddr, apiServer)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer server.Close()
+	}
+	// Serving the web dashboard
+	var dashboardServer *dashboard.Server
+	if config.DashboardAddr != "" {
+		dashboardServer = dashboard.NewServer(config.Timeframes)
+		server, err := serve(config.DashboardAddr, dashboardServer)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer server.Close()
+	}
+	// Exporting the checks and statistics to time series databases, whose failures are received on the exportFailures channel
+	exportFailures := make(chan timeseries.Failure, 16)
+	var seriesExporters []*timeseries.Exporter
+	var traceExporters []*otlp.TraceExporter
+	for _, exporterConfig := range config.Exporters {
+		seriesExporter := newSeriesExporter(exporterConfig, exportFailures)
+		defer seriesExporter.Close()
+		seriesExporters = append(seriesExporters, seriesExporter)
+		if exporterConfig.Traces {
+			traceExporter := otlp.NewTraceExporter(cli.Redact("otlp traces "+exporterConfig.URL), otlp.NewClient(exporterConfig.URL, exporterConfig.Headers), exporterConfig.BatchSize, exporterConfig.BufferSize, exporterConfig.FlushIntervalDuration, exportFailures)
+			defer traceExporter.Close()
+			traceExporters = append(traceExporters, traceExporter)
+		}
+	}
+	// Starting to check the websites
+	websites := &registry{
+		timeframes:      config.Timeframes,
+		timeout:         config.Timeout,
+		statsMessage:    statsMessage,
+		store:           store,
+		tracker:         tracker,
+		incidentsWindow: config.HistoryRetention.Raw,
+		exporter:        exporter,
+		apiServer:       apiServer,
+		dashboard:       dashboardServer,
+		tracing:         len(traceExporters) > 0,
+		websites:        make(map[string]*website),
+		loading:         make(map[string]bool),
+	}
+	for _, url := range config.Urls {
+		if err := websites.add(url, config.Websites[url]); err != nil {
+			websites.stop()
+			fmt.Println(cli.Redact(err.Error()))
+			return
+		}
+	}
+	loaded := make(chan loadedWebsite)
+	// Quitting on SIGINT and SIGTERM as well, so that the history is written
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	// Display tickers will refresh the stats display of each timeframe
+	refresh := make(chan int)
+	for id, timeframe := range config.Timeframes {
+		displayTicker := time.NewTicker(display.RefreshPeriod(timeframe))
+		defer displayTicker.Stop()
+		go func(id int, displayTicker *time.Ticker) {
+			for range displayTicker.C {
+				refresh <- id
+			}
+		}(id, displayTicker)
+	}
 	// Setting up the UI display
 	uiView := display.View{
-		UIEnabled:       uiEnabled,
-		Urls:            urls,
-		URLStatistics:   urlStatistics,
-		TimeframeRepr:   map[int]string{0: "2min", 1: "10min", 2: "1h"},
-		ActiveWebsite:   0,
-		ActiveTimeframe: 1,
-		AlertMessages:   []string{},
-		AlertOffset:     0,
+		UIEnabled:     config.UIEnabled,
+		Output:        config.Output,
+		Incidents:     tracker,
+		Timeframes:    config.Timeframes,
+		ActiveWebsite: 0,
+		AlertMessages: []string{},
+		AlertOffset:   0,
 	}
+	uiView.ActiveTimeframe = uiView.DefaultTimeframe()
+	if apiServer != nil || dashboardServer != nil {
+		uiView.OnAlert = func(alert catalog.Alert) {
+			if apiServer != nil {
+				apiServer.AddAlert(alert)
+			}
+			if dashboardServer != nil {
+				dashboardServer.AddAlert(alert)
+			}
+		}
+	}
+	websites.updateView(&uiView)
 	uiEvents := display.Init(uiView)
 	defer display.Close(uiView)
+	if uiView.UIEnabled {
+		// Laying the panels out for the size of the terminal
+		uiView.Width, uiView.Height = display.TerminalSize()
+	}
 	display.RenderLayout(uiView)
 	// Listening to tickers and UI Events
 	var previousAvailability, currentAvailability float64
@@ -61,75 +430,171 @@ func main() {
 		select {
 		// Catching the result of a Check operation
 		case stats := <-statsMessage:
+			site, ok := websites.websites[stats.URL]
+			if !ok || site.id != stats.Generation {
+				// The website was removed while being checked, and possibly added again
+				break
+			}
+			// Failure reasons may quote the URL, they are redacted once for every use
+			stats.Reason = site.config.Redact(stats.Reason)
+			// Updating the records
+			if store != nil {
+				store.Append(history.Record{
+					URL:          site.config.HistoryKey(),
+					Time:         stats.Time,
+					ResponseTime: stats.ResponseTime,
+					StatusCode:   stats.StatusCode,
+					Reason:       stats.Reason,
+				})
+			}
+			display.RenderCheck(uiView, stats)
+			tracker.Record(stats.URL, incidents.Sample{Time: stats.Time, ResponseTime: stats.ResponseTime, StatusCode: stats.StatusCode, Reason: stats.Reason})
+			for _, urlStatistic := range site.statistics {
+				urlStatistic.AddSample(statistics.Sample{Time: stats.Time, ResponseTime: stats.ResponseTime, StatusCode: stats.StatusCode, Reason: stats.Reason})
+			}
+			// Handeling alerts with the 2 min timeframe stats
 			// Pulling the previous availability
-			previousAvailability = urlStatistics[stats.URL][0].Availability()
-			for i, urlStatistic := range urlStatistics[stats.URL] {
-				// Updating the records
-				urlStatistic.AddRecord(stats.ResponseTime, stats.StatusCode)
-				// Handeling alerts with the 2 min timeframe stats
-				if i == 0 {
-					currentAvailability = urlStatistic.Availability()
-					// If 80% threshold is crossed, or website is unavailable from the start
-					if currentAvailability < 0.8 && (previousAvailability >= 0.8 || math.IsNaN(previousAvailability)) {
-						// Append the alert
-						uiView.AlertMessages = append(uiView.AlertMessages,
-							fmt.Sprintf("Website %s is down. availability=%.0f %%, time=%v",
-								display.Shorten(stats.URL),
-								currentAvailability*100.0,
-								time.Now().Format(time.Kitchen),
-							))
-						// Update the UI
-						go display.RenderAlerts(uiView)
-					}
-					// If availability is back above the 80% threshold
-					if currentAvailability >= 0.8 && previousAvailability < 0.8 {
-						// Append the alert
-						uiView.AlertMessages = append(uiView.AlertMessages,
-							fmt.Sprintf("Website %s is up, time=%v",
-								display.Shorten(stats.URL),
-								time.Now().Format(time.Kitchen),
-							))
-						// Update the UI
-						go display.RenderAlerts(uiView)
-					}
+			previousAvailability = site.alertStatistic.Availability()
+			site.alertStatistic.AddRecord(stats.Time, stats.ResponseTime, stats.StatusCode)
+			currentAvailability = site.alertStatistic.Availability()
+			checkAvailability(&uiView, store, site.config.HistoryKey(), stats, previousAvailability, currentAvailability)
+			// Handeling the burn rate alerts of the service level objectives
+			for _, objective := range site.objectives {
+				objective.AddRecord(stats.Time, stats.ResponseTime, stats.StatusCode)
+			}
+			checkBurnRates(&uiView, stats.URL, burning)
+			// Handeling the response time anomaly alerts
+			if site.detector != nil {
+				wasAnomalous := site.detector.Anomalous()
+				site.detector.AddRecord(stats.Time, stats.ResponseTime, stats.StatusCode)
+				checkAnomaly(&uiView, stats, site.detector, wasAnomalous)
+			}
+			// Handeling the content change alerts of successful checks
+			if site.watcher != nil && stats.StatusCode == 200 {
+				checkContent(&uiView, stats, site.watcher)
+			}
+			if exporter != nil {
+				exportMetrics(exporter, stats, currentAvailability, site.objectives, burning, site.detector)
+			}
+			if dashboardServer != nil {
+				dashboardServer.Record(stats.URL, stats.Time, stats.ResponseTime, stats.StatusCode)
+			}
+			if len(seriesExporters) > 0 {
+				exportPoints(seriesExporters, timeseries.CheckPoint(site.config.Label, site.config.Tags, stats.Time, stats.ResponseTime, stats.StatusCode))
+			}
+			if stats.Trace != nil && len(traceExporters) > 0 {
+				exportSpans(traceExporters, stats, site.config.Label, site.config.Tags)
+			}
+		// Display Tickers
+		case timeframe := <-refresh:
+			go display.RenderStats(uiView, timeframe)
+			if dashboardServer != nil {
+				go dashboardServer.Refresh()
+			}
+			if timeframe == uiView.ActiveTimeframe {
+				go display.RenderSLOs(uiView)
+			}
+			if len(seriesExporters) > 0 {
+				now := time.Now()
+				for _, url := range websites.urls {
+					site := websites.websites[url]
+					exportPoints(seriesExporters, timeseries.StatsPoint(site.config.Label, site.config.Tags, cli.FormatDuration(config.Timeframes[timeframe]), now, site.statistics[timeframe].Snapshot()))
 				}
 			}
-		// 10 min display Ticker
-		case <-displayTicker1.C:
-			go display.RenderStats(uiView, 1)
-		// 1 h display Ticker
-		case <-displayTicker2.C:
-			go display.RenderStats(uiView, 2)
+		// Failures and recoveries of the time series database exporters
+		case failure := <-exportFailures:
+			checkExport(&uiView, failure)
+		// Failures and recoveries of the history file
+		case failure := <-historyFailures:
+			checkHistory(&uiView, failure)
+		// Management requests of the API, and the websites they add once loaded from the history
+		case command := <-commands:
+			handleCommand(&uiView, websites, burning, loaded, command)
+		case site := <-loaded:
+			handleLoaded(&uiView, websites, site)
+		// Termination signals
+		case <-interrupt:
+			websites.stop()
+			return
 		// UI events
 		case e := <-uiEvents:
+			if uiView.Filtering && e.ID != "<C-c>" && e.ID != "<Resize>" {
+				// Typing the filter of the websites
+				typeFilter(&uiView, e.ID)
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+				break
+			}
 			switch e.ID {
 			case "q", "<C-c>":
-				// Sending as many stop messages as there are Fetch goroutines running
-				for range urls {
-					stop <- struct{}{}
-				}
+				// Stopping the Fetch goroutines
+				websites.stop()
 				return
-			case "<Up>":
-				// Scrolling up
+			case "<Resize>":
+				// Laying the panels out again for the new size of the terminal
+				uiView.Width, uiView.Height = display.ResizeSize(e)
+				display.RenderAll(uiView)
+			case "<PageUp>":
+				// Scrolling up the alerts
 				if uiView.AlertOffset > 0 {
 					uiView.AlertOffset--
 				}
 				go display.RenderAlerts(uiView)
-			case "<Down>":
-				// Scrolling down
+			case "<PageDown>":
+				// Scrolling down the alerts
 				if uiView.AlertOffset < len(uiView.AlertMessages)-1 {
 					uiView.AlertOffset++
 				}
 				go display.RenderAlerts(uiView)
+			case "<Up>", "k":
+				// Selecting the previous website of the list
+				uiView.MoveSelection(-1)
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "<Down>", "j":
+				// Selecting the next website of the list
+				uiView.MoveSelection(1)
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "o":
+				// Cycling through the columns the websites are sorted by
+				uiView.SortColumn = uiView.NextSortColumn()
+				uiView.MoveSelection(0)
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "O":
+				// Reversing the order of the websites
+				uiView.SortDescending = !uiView.SortDescending
+				uiView.MoveSelection(0)
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "/":
+				// Typing a filter of the websites
+				uiView.Filtering = true
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "i":
+				// Toggling the incidents of the active website
+				uiView.ShowIncidents = !uiView.ShowIncidents
+				uiView.ShowContent = false
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "c":
+				// Toggling the last content change of the active website
+				uiView.ShowContent = !uiView.ShowContent
+				uiView.ShowIncidents = false
+				go display.RenderStats(uiView, uiView.ActiveTimeframe)
 			case "s":
-				// Switching the active view between 1 and 2
-				uiView.ActiveTimeframe = 3 - uiView.ActiveTimeframe
+				// Cycling through the timeframes
+				uiView.ActiveTimeframe = uiView.NextTimeframe()
 				go display.RenderStats(uiView, uiView.ActiveTimeframe)
+			case "e", "E":
+				// Exporting the samples of the active website over the active timeframe, in CSV or JSON
+				format := "csv"
+				if e.ID == "E" {
+					format = "json"
+				}
+				uiView.AlertMessages = append(uiView.AlertMessages, exportSamples(uiView, format))
+				go display.RenderAlerts(uiView)
 			}
-			// If the pressed key is a number within the number of websites' range
+			// If the pressed key is the id of a website
 			v, err := strconv.Atoi(e.ID)
-			if err == nil && v < len(urls) {
-				uiView.ActiveWebsite = v
+			if index, ok := uiView.WebsiteIndex(v); err == nil && ok {
+				uiView.ActiveWebsite = index
+				uiView.MoveSelection(0)
 				// Updating Statistics layout and views
 				go display.RenderStats(uiView, uiView.ActiveTimeframe)
 			}
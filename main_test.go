@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/display"
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+func TestExportedRecords(t *testing.T) {
+	// Test if a period partly downsampled to rollups is refused, and the raw records of a later period exported
+	store, remove := openHistory(t)
+	defer remove()
+	now := time.Now().Truncate(time.Minute)
+	url := "https://example.com"
+	for _, r := range []history.Record{
+		{URL: url, Time: now.Add(-48 * time.Hour), ResponseTime: 100, StatusCode: 200},
+		{URL: url, Time: now.Add(-2 * time.Minute), ResponseTime: 200, StatusCode: 200},
+		{URL: url, Time: now.Add(-time.Minute), ResponseTime: 300, StatusCode: 500},
+	} {
+		store.Append(r)
+	}
+	if err := store.Compact(now); err != nil {
+		t.Fatalf("Compact returned %v", err)
+	}
+
+	if _, err := exportedRecords(store, url, now.Add(-72*time.Hour), now); err == nil {
+		t.Errorf("exportedRecords() of a downsampled period returned no error")
+	}
+	records, err := exportedRecords(store, url, now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatalf("exportedRecords returned %v", err)
+	}
+	if len(records) != 2 || records[0].ResponseTime != 200 || records[1].ResponseTime != 300 {
+		t.Errorf("exportedRecords() == %v, want the 2 raw records", records)
+	}
+}
+
+func TestTypeFilter(t *testing.T) {
+	uiView := &display.View{
+		Urls:   []string{"https://a.example.com", "https://b.example.org"},
+		Labels: map[string]string{"https://a.example.com": "https://a.example.com", "https://b.example.org": "https://b.example.org"},
+		URLStatistics: map[string][]*statistics.Statistic{
+			"https://a.example.com": {statistics.NewStatistic(time.Minute, 0)},
+			"https://b.example.org": {statistics.NewStatistic(time.Minute, 0)},
+		},
+		Timeframes: []time.Duration{time.Minute},
+		Width:      150,
+		Height:     50,
+	}
+	tests := []struct {
+		key           string
+		wantFilter    string
+		wantFiltering bool
+		wantActive    int
+	}{
+		{"o", "o", true, 0},
+		{"r", "or", true, 1},
+		{"<Backspace>", "o", true, 1},
+		{"<Space>", "o ", true, 1},
+		{"<Backspace>", "o", true, 1},
+		{"<Enter>", "o", false, 1},
+		{"<Escape>", "", false, 1},
+	}
+	uiView.Filtering = true
+	for _, test := range tests {
+		typeFilter(uiView, test.key)
+		if uiView.Filter != test.wantFilter || uiView.Filtering != test.wantFiltering || uiView.ActiveWebsite != test.wantActive {
+			t.Errorf("typeFilter(%q) == %q, %v, %v, want %q, %v, %v", test.key, uiView.Filter, uiView.Filtering, uiView.ActiveWebsite,
+				test.wantFilter, test.wantFiltering, test.wantActive)
+		}
+	}
+}
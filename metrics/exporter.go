@@ -0,0 +1,247 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+)
+
+// responseTimeBuckets are the upper bounds in seconds of the response time histogram buckets
+var responseTimeBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// reservedLabels are the label names of the metrics, which tags cannot override
+var reservedLabels = map[string]bool{"url": true, "timeframe": true, "code": true, "alert": true, "objective": true, "le": true}
+
+// invalidLabelCharacters are the characters not allowed in a label name
+var invalidLabelCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Exporter exposes the metrics of the websites in the Prometheus text exposition format
+type Exporter struct {
+	mutex      sync.Mutex
+	timeframes []time.Duration
+	// Monitored websites, in the order they were added, and their metrics by URL
+	websites []catalog.Website
+	metrics  map[string]*website
+}
+
+// website holds the metrics of a website
+type website struct {
+	// Labels of every series of the website, such as url="https://example.com",env="prod"
+	labels     string
+	lastStatus int
+	lastCheck  time.Time
+	checks     map[int]uint64
+	// Cumulative counts of the response time histogram buckets, their sum in seconds and their count
+	buckets []uint64
+	sum     float64
+	count   uint64
+	// Alert states, by alert and objective
+	alerts map[alert]bool
+}
+
+// alert identifies an alert of a website, optionally about one of its objectives
+type alert struct {
+	name      string
+	objective string
+}
+
+// NewExporter returns a new Exporter, exposing the availability over the given timeframes
+func NewExporter(timeframes []time.Duration) *Exporter {
+	return &Exporter{timeframes: timeframes, metrics: make(map[string]*website)}
+}
+
+// SetWebsites sets the monitored websites, in their order. Their series are labelled with their URL label and their tags.
+// The metrics of the websites no longer monitored are dropped.
+func (e *Exporter) SetWebsites(websites []catalog.Website) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	metrics := make(map[string]*website, len(websites))
+	for _, site := range websites {
+		w, ok := e.metrics[site.URL]
+		if !ok {
+			w = &website{
+				labels:  labels(site),
+				checks:  make(map[int]uint64),
+				buckets: make([]uint64, len(responseTimeBuckets)),
+				alerts:  make(map[alert]bool),
+			}
+		}
+		metrics[site.URL] = w
+	}
+	e.websites = websites
+	e.metrics = metrics
+}
+
+// labels returns the labels of every series of a website
+func labels(site catalog.Website) string {
+	labels := []string{"url=" + quote(site.Label)}
+	names := make([]string, 0, len(site.Config.Tags))
+	for name := range site.Config.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Tags whose names are sanitized to the same label name are told apart by a suffix, in the order of their names
+	used := make(map[string]bool)
+	for _, name := range names {
+		sanitized := labelName(name)
+		if reservedLabels[sanitized] {
+			continue
+		}
+		labelName := sanitized
+		for suffix := 2; used[labelName]; suffix++ {
+			labelName = fmt.Sprintf("%s_%d", sanitized, suffix)
+		}
+		used[labelName] = true
+		labels = append(labels, labelName+"="+quote(site.Config.Tags[name]))
+	}
+	return strings.Join(labels, ",")
+}
+
+// Record adds a check of a website to its metrics
+func (e *Exporter) Record(url string, t time.Time, responseTime int, statusCode int) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	w, ok := e.metrics[url]
+	if !ok {
+		return
+	}
+	w.lastStatus = statusCode
+	w.lastCheck = t
+	w.checks[statusCode]++
+	seconds := float64(responseTime) / 1000
+	for i, bound := range responseTimeBuckets {
+		if seconds <= bound {
+			w.buckets[i]++
+		}
+	}
+	w.sum += seconds
+	w.count++
+}
+
+// SetAlert sets whether an alert of a website is firing. The objective is the name of the service level objective it is about, if any.
+func (e *Exporter) SetAlert(url string, name string, objective string, firing bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if w, ok := e.metrics[url]; ok {
+		w.alerts[alert{name, objective}] = firing
+	}
+}
+
+// ServeHTTP writes the metrics of the websites
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.Write(w)
+}
+
+// Write writes the metrics of the websites in the Prometheus text exposition format
+func (e *Exporter) Write(w io.Writer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	header(w, "webmonitor_last_status_code", "gauge", "Status code of the last check, 408 when it timed out or failed.")
+	for _, monitored := range e.websites {
+		if site := e.metrics[monitored.URL]; site.count > 0 {
+			fmt.Fprintf(w, "webmonitor_last_status_code{%s} %d\n", site.labels, site.lastStatus)
+		}
+	}
+	header(w, "webmonitor_last_check_timestamp_seconds", "gauge", "Unix time of the last check.")
+	for _, monitored := range e.websites {
+		if site := e.metrics[monitored.URL]; site.count > 0 {
+			fmt.Fprintf(w, "webmonitor_last_check_timestamp_seconds{%s} %s\n", site.labels, formatFloat(float64(site.lastCheck.UnixNano())/1e9))
+		}
+	}
+	header(w, "webmonitor_checks_total", "counter", "Number of checks by status code.")
+	for _, monitored := range e.websites {
+		site := e.metrics[monitored.URL]
+		codes := make([]int, 0, len(site.checks))
+		for code := range site.checks {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "webmonitor_checks_total{%s,code=\"%d\"} %d\n", site.labels, code, site.checks[code])
+		}
+	}
+	header(w, "webmonitor_response_time_seconds", "histogram", "Response time of the checks.")
+	for _, monitored := range e.websites {
+		site := e.metrics[monitored.URL]
+		for i, bound := range responseTimeBuckets {
+			fmt.Fprintf(w, "webmonitor_response_time_seconds_bucket{%s,le=\"%s\"} %d\n", site.labels, formatFloat(bound), site.buckets[i])
+		}
+		fmt.Fprintf(w, "webmonitor_response_time_seconds_bucket{%s,le=\"+Inf\"} %d\n", site.labels, site.count)
+		fmt.Fprintf(w, "webmonitor_response_time_seconds_sum{%s} %s\n", site.labels, formatFloat(site.sum))
+		fmt.Fprintf(w, "webmonitor_response_time_seconds_count{%s} %d\n", site.labels, site.count)
+	}
+	header(w, "webmonitor_availability_ratio", "gauge", "Fraction of successful checks over each timeframe, NaN without checks.")
+	for _, monitored := range e.websites {
+		site := e.metrics[monitored.URL]
+		for id, statistic := range monitored.Statistics {
+			fmt.Fprintf(w, "webmonitor_availability_ratio{%s,timeframe=%s} %s\n", site.labels, quote(cli.FormatDuration(e.timeframes[id])), formatFloat(statistic.Availability()))
+		}
+	}
+	header(w, "webmonitor_apdex_score", "gauge", "Apdex score of the checks over each timeframe, NaN without checks.")
+	for _, monitored := range e.websites {
+		site := e.metrics[monitored.URL]
+		for id, statistic := range monitored.Statistics {
+			fmt.Fprintf(w, "webmonitor_apdex_score{%s,timeframe=%s} %s\n", site.labels, quote(cli.FormatDuration(e.timeframes[id])), formatFloat(statistic.Apdex()))
+		}
+	}
+	header(w, "webmonitor_alert_firing", "gauge", "Whether an alert is firing.")
+	for _, monitored := range e.websites {
+		site := e.metrics[monitored.URL]
+		alerts := make([]alert, 0, len(site.alerts))
+		for a := range site.alerts {
+			alerts = append(alerts, a)
+		}
+		sort.Slice(alerts, func(i, j int) bool {
+			if alerts[i].name != alerts[j].name {
+				return alerts[i].name < alerts[j].name
+			}
+			return alerts[i].objective < alerts[j].objective
+		})
+		for _, a := range alerts {
+			labels := site.labels + ",alert=" + quote(a.name)
+			if a.objective != "" {
+				labels += ",objective=" + quote(a.objective)
+			}
+			firing := 0
+			if site.alerts[a] {
+				firing = 1
+			}
+			fmt.Fprintf(w, "webmonitor_alert_firing{%s} %d\n", labels, firing)
+		}
+	}
+}
+
+// header writes the help and type lines of a metric
+func header(w io.Writer, name string, metricType string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// quote returns a quoted label value, escaping its backslashes, double quotes and line feeds
+func quote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+// labelName returns a valid label name from a tag name
+func labelName(name string) string {
+	name = invalidLabelCharacters.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+// formatFloat formats a sample value, such as 0.25, NaN or +Inf
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
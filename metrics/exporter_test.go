@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+func TestExporter(t *testing.T) {
+	now := time.Now()
+	statistic := statistics.NewStatistic(2*time.Minute, 500)
+	e := NewExporter([]time.Duration{2 * time.Minute})
+	e.SetWebsites([]catalog.Website{{
+		URL:        "https://example.com/?token=secret",
+		Label:      "https://example.com/?token=****",
+		Config:     cli.Website{Tags: map[string]string{"env": "prod", "team-name": "web", "team.name": "front", "url": "ignored"}},
+		Statistics: []*statistics.Statistic{statistic},
+	}})
+	for _, check := range []struct {
+		responseTime int
+		statusCode   int
+	}{{80, 200}, {1200, 200}, {5000, 408}, {120, 200}} {
+		statistic.AddRecord(now, check.responseTime, check.statusCode)
+		e.Record("https://example.com/?token=secret", now, check.responseTime, check.statusCode)
+	}
+	e.SetAlert("https://example.com/?token=secret", "availability", "", true)
+	e.SetAlert("https://example.com/?token=secret", "fast_burn", "latency \"p95\"", false)
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type == %q, want the text exposition format", contentType)
+	}
+	body := recorder.Body.String()
+	labels := `url="https://example.com/?token=****",env="prod",team_name="web",team_name_2="front"`
+	for _, line := range []string{
+		"# TYPE webmonitor_response_time_seconds histogram",
+		"webmonitor_last_status_code{" + labels + "} 200",
+		"webmonitor_checks_total{" + labels + `,code="200"} 3`,
+		"webmonitor_checks_total{" + labels + `,code="408"} 1`,
+		"webmonitor_response_time_seconds_bucket{" + labels + `,le="0.1"} 1`,
+		"webmonitor_response_time_seconds_bucket{" + labels + `,le="0.25"} 2`,
+		"webmonitor_response_time_seconds_bucket{" + labels + `,le="+Inf"} 4`,
+		"webmonitor_response_time_seconds_sum{" + labels + "} 6.4",
+		"webmonitor_availability_ratio{" + labels + `,timeframe="2min"} 0.75`,
+		"webmonitor_apdex_score{" + labels + `,timeframe="2min"} 0.625`,
+		"webmonitor_alert_firing{" + labels + `,alert="availability"} 1`,
+		"webmonitor_alert_firing{" + labels + `,alert="fast_burn",objective="latency \"p95\""} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics do not contain %q :\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "secret") {
+		t.Errorf("metrics contain a secret :\n%s", body)
+	}
+}
+
+func TestExporterSetWebsites(t *testing.T) {
+	now := time.Now()
+	e := NewExporter([]time.Duration{2 * time.Minute})
+	first := catalog.Website{URL: "https://example.com", Label: "https://example.com", Statistics: []*statistics.Statistic{statistics.NewStatistic(2*time.Minute, 500)}}
+	second := catalog.Website{URL: "https://example.org", Label: "https://example.org", Statistics: []*statistics.Statistic{statistics.NewStatistic(2*time.Minute, 500)}}
+	e.SetWebsites([]catalog.Website{first, second})
+	e.Record(first.URL, now, 100, 200)
+	e.Record(second.URL, now, 100, 200)
+	// The metrics of the remaining websites are kept, those of the removed ones are dropped
+	e.SetWebsites([]catalog.Website{second})
+	e.Record(first.URL, now, 100, 200)
+	e.Record(second.URL, now, 100, 500)
+	var body strings.Builder
+	e.Write(&body)
+	if strings.Contains(body.String(), "example.com") {
+		t.Errorf("metrics contain the removed website :\n%s", body.String())
+	}
+	for _, line := range []string{
+		`webmonitor_checks_total{url="https://example.org",code="200"} 1`,
+		`webmonitor_checks_total{url="https://example.org",code="500"} 1`,
+	} {
+		if !strings.Contains(body.String(), line+"\n") {
+			t.Errorf("metrics do not contain %q :\n%s", line, body.String())
+		}
+	}
+}
@@ -1,52 +1,145 @@
 package monitor
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptrace"
 	"time"
 )
 
+// maxBodySize is the number of bytes of a response body read at most, longer bodies being cut
+const maxBodySize = 1 << 20
+
 // CheckStats is a data structure to store and send results from the CheckWithTimeout function
 type CheckStats struct {
-	URL          string
+	URL string
+	// Generation of the checked target
+	Generation int
+	// Time at which the check started. Concurrent checks may complete, and be sent, in another order.
+	Time         time.Time
 	ResponseTime int
 	StatusCode   int
+	// Reason of a failed check, empty if the check succeeded
+	Reason string
+	// Response body, only read if the target asks for it, cut to its first maxBodySize bytes
+	Body []byte
+	// Trace of the check, only recorded if the target asks for it
+	Trace *Trace
+}
+
+// Target describes a website to check and how to request it
+type Target struct {
+	URL string
+	// Generation of the target, sent back with its stats so that they can be told apart from those of a previous target
+	// checking the same URL, such as a website removed and added again while it was being checked
+	Generation int
+	// Headers sent along with the request, such as an authentication token
+	Headers map[string]string
+	// Whether the response body is read and sent back with the stats
+	ReadBody bool
+	// Whether the check is traced, and its traceparent header sent to the website
+	Trace bool
 }
 
 // CheckWithTimeout Checks a website, and returns the current response time and response code of a website, unless it times out.
-func CheckWithTimeout(url string, timeout int) CheckStats {
+func CheckWithTimeout(target Target, timeout int) CheckStats {
+	url := target.URL
 	client := http.Client{
 		Timeout: time.Duration(timeout) * time.Second,
 	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		// The request cannot be built, the website is unreachable
+		return CheckStats{URL: url, Generation: target.Generation, Time: time.Now(), StatusCode: 408, Reason: err.Error()}
+	}
+	for key, value := range target.Headers {
+		req.Header.Set(key, value)
+	}
+	var trace *Trace
+	if target.Trace {
+		if trace, err = newTrace(); err == nil {
+			req.Header.Set("traceparent", trace.Traceparent())
+			req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+		}
+	}
 	t := time.Now()
 	// Checking website
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	// Computing Total response time
 	responseTime := int(time.Now().Sub(t).Milliseconds())
 	// If there are no response, or a timeout
 	if err != nil {
 		// Using 408 to label no response or timeout issues
-		return CheckStats{url, responseTime, 408}
+		stats := CheckStats{URL: url, Generation: target.Generation, Time: t, ResponseTime: responseTime, StatusCode: 408, Reason: err.Error(), Trace: trace}
+		if trace != nil {
+			trace.finish(time.Now())
+		}
+		return stats
 	}
 	defer resp.Body.Close()
-	return CheckStats{url, responseTime, resp.StatusCode}
+	stats := CheckStats{URL: url, Generation: target.Generation, Time: t, ResponseTime: responseTime, StatusCode: resp.StatusCode, Trace: trace}
+	if resp.StatusCode != 200 {
+		stats.Reason = resp.Status
+	}
+	if target.ReadBody {
+		if stats.Body, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize)); err != nil {
+			// A body cut short is not the content of the website
+			stats.StatusCode = 408
+			stats.Reason = err.Error()
+		}
+	}
+	if trace != nil {
+		trace.finish(time.Now())
+	}
+	return stats
 }
 
-// CheckOnTicks Regularly checks a website, and send back the stats as a channel message.
-func CheckOnTicks(url string, checkInterval int, timeout int, stop chan struct{}, statsMessage chan CheckStats) {
+// Command controls the checks of a CheckOnTicks goroutine
+type Command int
+
+const (
+	// Stop stops the checks
+	Stop Command = iota
+	// Pause skips the periodic checks until Resume
+	Pause
+	// Resume resumes the periodic checks
+	Resume
+	// CheckNow checks the website right away, even if the checks are paused
+	CheckNow
+)
+
+// CheckOnTicks Regularly checks a website, and send back the stats as a channel message. It is controlled by the commands it receives.
+func CheckOnTicks(target Target, checkInterval int, timeout int, commands chan Command, statsMessage chan CheckStats) {
 	// Data will be fetched at every checkInterval
 	fetchTicker := time.NewTicker(time.Second * time.Duration(checkInterval))
 	defer fetchTicker.Stop()
+	// Checking the website within a goroutine and send back the results
+	check := func() {
+		go func() {
+			statsMessage <- CheckWithTimeout(target, timeout)
+		}()
+	}
+	paused := false
 	for {
 		select {
-		// Message to stop the goroutine and ticker
-		case <-stop:
-			return
+		case command := <-commands:
+			switch command {
+			// Message to stop the goroutine and ticker
+			case Stop:
+				return
+			case Pause:
+				paused = true
+			case Resume:
+				paused = false
+			case CheckNow:
+				check()
+			}
 		// Fetch Ticker
 		case <-fetchTicker.C:
-			// Checking the website within a goroutine and send back the results
-			go func() {
-				statsMessage <- CheckWithTimeout(url, timeout)
-			}()
+			if !paused {
+				check()
+			}
 		}
 	}
 }
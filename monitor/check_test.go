@@ -1,7 +1,11 @@
 package monitor
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 )
 
 func TestCheckStatusCode(t *testing.T) {
@@ -18,7 +22,7 @@ func TestCheckStatusCode(t *testing.T) {
 	}
 	var statResult CheckStats
 	for _, c := range cases {
-		statResult = CheckWithTimeout(c.in, 5)
+		statResult = CheckWithTimeout(Target{URL: c.in}, 5)
 		got := statResult.StatusCode
 		if got != c.want {
 			t.Errorf("Reverse(%q) == %v, want %v", c.in, got, c.want)
@@ -39,10 +43,53 @@ func TestCheckResponseTime(t *testing.T) {
 	}
 	var statResult CheckStats
 	for _, c := range cases {
-		statResult = CheckWithTimeout(c.in, 5)
+		statResult = CheckWithTimeout(Target{URL: c.in}, 5)
 		got := statResult.ResponseTime
 		if got < c.want {
 			t.Errorf("Reverse(%q) < %v, want %v", c.in, got, c.want)
 		}
 	}
 }
+
+func TestCheckTrace(t *testing.T) {
+	// Test if the traceparent header is sent, and the phases of the request recorded
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+	stats := CheckWithTimeout(Target{URL: server.URL, Trace: true}, 5)
+	if stats.Trace == nil {
+		t.Fatalf("Trace == nil, want the trace of the check")
+	}
+	if !regexp.MustCompile("^00-[0-9a-f]{32}-[0-9a-f]{16}-01$").MatchString(traceparent) || traceparent != stats.Trace.Traceparent() {
+		t.Errorf("traceparent == %q, want %q", traceparent, stats.Trace.Traceparent())
+	}
+	phases := make(map[string]Phase)
+	for _, phase := range stats.Trace.Phases {
+		phases[phase.Name] = phase
+	}
+	if _, ok := phases["connect"]; !ok {
+		t.Errorf("Phases == %v, want a connect phase", stats.Trace.Phases)
+	}
+	if server, ok := phases["server"]; !ok || server.End.Sub(server.Start) < 20*time.Millisecond || stats.Trace.End.Before(server.End) {
+		t.Errorf("Phases == %v, want a server phase of at least 20ms within the check", stats.Trace.Phases)
+	}
+
+	// Checks are not traced unless asked
+	if stats = CheckWithTimeout(Target{URL: server.URL}, 5); stats.Trace != nil || traceparent != "" {
+		t.Errorf("Trace == %v, traceparent == %q, want no trace", stats.Trace, traceparent)
+	}
+}
+
+func TestCheckGeneration(t *testing.T) {
+	// Test if the generation of the target is sent back, whether the check succeeds or not
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	for _, url := range []string{server.URL, "http://localhost:1", "://invalid"} {
+		if stats := CheckWithTimeout(Target{URL: url, Generation: 3}, 5); stats.Generation != 3 {
+			t.Errorf("CheckWithTimeout(%v).Generation == %v, want 3", url, stats.Generation)
+		}
+	}
+}
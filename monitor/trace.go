@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Trace is the trace of a check : the ids of its span, propagated to the website, and the phases of its request
+type Trace struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// Time at which the check ended
+	End    time.Time
+	Phases []Phase
+	mutex  sync.Mutex
+	starts map[string]time.Time
+	done   bool
+}
+
+// Phase is a phase of the request of a check, such as the DNS lookup
+type Phase struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// newTrace returns a new Trace with random ids
+func newTrace() (*Trace, error) {
+	trace := &Trace{starts: make(map[string]time.Time)}
+	if _, err := rand.Read(trace.TraceID[:]); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(trace.SpanID[:]); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// Traceparent returns the W3C traceparent header of the trace, sampled, such as 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (t *Trace) Traceparent() string {
+	return "00-" + hex.EncodeToString(t.TraceID[:]) + "-" + hex.EncodeToString(t.SpanID[:]) + "-01"
+}
+
+// clientTrace returns the hooks recording the phases of the request : dns, connect, tls and server, from the request
+// written to the first response byte
+func (t *Trace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.start("dns") },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.end("dns") },
+		ConnectStart:         func(string, string) { t.start("connect") },
+		ConnectDone:          func(string, string, error) { t.end("connect") },
+		TLSHandshakeStart:    func() { t.start("tls") },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.end("tls") },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.start("server") },
+		GotFirstResponseByte: func() { t.end("server") },
+	}
+}
+
+// start records the start of a phase
+func (t *Trace) start(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, started := t.starts[name]; !started {
+		t.starts[name] = time.Now()
+	}
+}
+
+// end records the end of a started phase, unless the check is over
+func (t *Trace) end(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	start, started := t.starts[name]
+	if !started || t.done {
+		return
+	}
+	delete(t.starts, name)
+	t.Phases = append(t.Phases, Phase{Name: name, Start: start, End: time.Now()})
+}
+
+// finish ends the trace, so that the phases of the connections still opening are ignored
+func (t *Trace) finish(end time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.End = end
+	t.done = true
+}
@@ -0,0 +1,109 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeout is how long to wait for the response of the collector
+const requestTimeout = 5 * time.Second
+
+// Paths of the OTLP/HTTP endpoints of a collector
+const (
+	MetricsPath = "/v1/metrics"
+	TracesPath  = "/v1/traces"
+)
+
+// Client posts OTLP/HTTP requests to a collector, in the JSON encoding
+type Client struct {
+	endpoint string
+	// Headers of the requests, such as an API key
+	headers map[string]string
+	client  http.Client
+}
+
+// NewClient returns a new Client of a collector endpoint, such as http://localhost:4318
+func NewClient(endpoint string, headers map[string]string) *Client {
+	return &Client{endpoint: strings.TrimSuffix(endpoint, "/"), headers: headers, client: http.Client{Timeout: requestTimeout}}
+}
+
+// post posts a request to a path of the collector
+func (c *Client) post(path string, request interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector answered %s : %s", resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// resource is the OTLP resource of the telemetry of webmonitor
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+// scope is the OTLP instrumentation scope of the telemetry of webmonitor
+type scope struct {
+	Name string `json:"name"`
+}
+
+// webmonitorResource is the resource of every request, the service emitting the telemetry
+var webmonitorResource = resource{Attributes: []keyValue{stringAttribute("service.name", "webmonitor")}}
+
+// webmonitorScope is the scope of every request
+var webmonitorScope = scope{Name: "github.com/hugo-sv/webmonitor"}
+
+// keyValue is an OTLP attribute
+type keyValue struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+// stringAttribute returns a string attribute
+func stringAttribute(key string, value string) keyValue {
+	return keyValue{Key: key, Value: map[string]string{"stringValue": value}}
+}
+
+// intAttribute returns an integer attribute. 64 bits integers are strings in the JSON encoding.
+func intAttribute(key string, value int) keyValue {
+	return keyValue{Key: key, Value: map[string]string{"intValue": strconv.Itoa(value)}}
+}
+
+// tagAttributes returns the string attributes of tags, sorted by name
+func tagAttributes(tags map[string]string) []keyValue {
+	attributes := make([]keyValue, 0, len(tags))
+	for key, value := range tags {
+		attributes = append(attributes, stringAttribute(key, value))
+	}
+	sort.Slice(attributes, func(i, j int) bool { return attributes[i].Key < attributes[j].Key })
+	return attributes
+}
+
+// unixNano returns a timestamp in nanoseconds, as a string of the JSON encoding
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,139 @@
+package otlp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/timeseries"
+)
+
+// collector is a stand-in OTLP/HTTP collector, keeping the requests it receives
+type collector struct {
+	metrics chan metricsRequest
+	traces  chan tracesRequest
+	headers chan http.Header
+}
+
+func newCollector() (*collector, *httptest.Server) {
+	c := &collector{metrics: make(chan metricsRequest, 10), traces: make(chan tracesRequest, 10), headers: make(chan http.Header, 10)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.headers <- r.Header
+		var err error
+		switch r.URL.Path {
+		case MetricsPath:
+			var request metricsRequest
+			err = json.NewDecoder(r.Body).Decode(&request)
+			c.metrics <- request
+		case TracesPath:
+			var request tracesRequest
+			err = json.NewDecoder(r.Body).Decode(&request)
+			c.traces <- request
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	return c, server
+}
+
+func TestMetrics(t *testing.T) {
+	c, server := newCollector()
+	defer server.Close()
+	now := time.Now()
+	point := timeseries.CheckPoint("https://example.com/?token=****", map[string]string{"env": "prod"}, now, 120, 200)
+	if err := NewMetrics(NewClient(server.URL+"/", map[string]string{"X-Api-Key": "secret"})).Send([]timeseries.Point{point}); err != nil {
+		t.Fatalf("Send returned %v", err)
+	}
+	if header := <-c.headers; header.Get("X-Api-Key") != "secret" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers == %v, want the API key and the JSON content type", header)
+	}
+	request := <-c.metrics
+	if len(request.ResourceMetrics) != 1 || len(request.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("request == %+v, want the metrics of webmonitor", request)
+	}
+	metrics := request.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 3 || metrics[0].Name != "webmonitor.check.response_time" || metrics[0].Unit != "ms" || metrics[1].Name != "webmonitor.check.status_code" {
+		t.Fatalf("metrics == %+v, want the response time, status code and success gauges", metrics)
+	}
+	dataPoint := metrics[0].Gauge.DataPoints[0]
+	if dataPoint.AsInt == nil || *dataPoint.AsInt != "120" || dataPoint.TimeUnixNano != unixNano(now) {
+		t.Errorf("data point == %+v, want 120 at %v", dataPoint, now)
+	}
+	if len(dataPoint.Attributes) != 2 || dataPoint.Attributes[0].Key != "env" || dataPoint.Attributes[1].Value["stringValue"] != "https://example.com/?token=****" {
+		t.Errorf("attributes == %+v, want the env and url tags", dataPoint.Attributes)
+	}
+}
+
+func TestTraces(t *testing.T) {
+	// A traced check of a website, whose spans are found by the traceparent it received
+	var traceparent string
+	website := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer website.Close()
+	stats := monitor.CheckWithTimeout(monitor.Target{URL: website.URL, Trace: true}, 5)
+
+	c, server := newCollector()
+	defer server.Close()
+	e := NewTraceExporter("otlp", NewClient(server.URL, nil), 100, 100, time.Hour, nil)
+	e.Add(CheckSpans(website.URL, map[string]string{"env": "prod"}, stats)...)
+	e.Close()
+	request := <-c.traces
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) < 3 {
+		t.Fatalf("spans == %+v, want the check, connect and server spans", spans)
+	}
+	check := spans[0]
+	if traceparent != "00-"+check.TraceID+"-"+check.SpanID+"-01" {
+		t.Errorf("traceparent == %q, want the ids of the check span %+v", traceparent, check)
+	}
+	if check.Kind != KindClient || check.Status == nil || check.Status.Code != StatusError || !strings.Contains(check.Status.Message, "503") {
+		t.Errorf("check span == %+v, want a failed client span", check)
+	}
+	for _, span := range spans[1:] {
+		if span.TraceID != check.TraceID || span.ParentSpanID != check.SpanID || span.SpanID == check.SpanID {
+			t.Errorf("span == %+v, want a child of the check span", span)
+		}
+	}
+}
+
+func TestTraceExporterBuffering(t *testing.T) {
+	// The spans are kept while the collector fails, and sent once it recovers
+	var recovered int32
+	received := make(chan int, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&recovered) == 0 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		var request tracesRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		received <- len(request.ResourceSpans[0].ScopeSpans[0].Spans)
+	}))
+	defer server.Close()
+	failures := make(chan timeseries.Failure, 10)
+	e := NewTraceExporter("otlp", NewClient(server.URL, nil), 2, 10, time.Hour, failures)
+	e.Add(Span{Name: "GET"}, Span{Name: "GET"})
+	if failure := <-failures; failure.Err == nil || !strings.Contains(failure.Err.Error(), "overloaded") {
+		t.Errorf("failure == %+v, want the error of the collector", failure)
+	}
+	atomic.StoreInt32(&recovered, 1)
+	e.Add(Span{Name: "GET"})
+	e.Close()
+	if count := <-received; count != 2 {
+		t.Errorf("batch of %d spans, want 2", count)
+	}
+	if count := <-received; count != 1 {
+		t.Errorf("batch of %d spans, want 1", count)
+	}
+}
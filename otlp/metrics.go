@@ -0,0 +1,103 @@
+package otlp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hugo-sv/webmonitor/timeseries"
+)
+
+// Metrics sends points to an OTLP collector as gauges, such as webmonitor.check.response_time in ms. It is a
+// timeseries backend, so that the points are batched and buffered as for the other time series databases.
+type Metrics struct {
+	client *Client
+}
+
+// NewMetrics returns a new Metrics backend of a collector client
+func NewMetrics(client *Client) *Metrics {
+	return &Metrics{client: client}
+}
+
+type metricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Gauge gauge  `json:"gauge"`
+}
+
+type gauge struct {
+	DataPoints []dataPoint `json:"dataPoints"`
+}
+
+type dataPoint struct {
+	Attributes   []keyValue `json:"attributes"`
+	TimeUnixNano string     `json:"timeUnixNano"`
+	AsInt        *string    `json:"asInt,omitempty"`
+	AsDouble     *float64   `json:"asDouble,omitempty"`
+}
+
+// Send sends points to the collector, a gauge data point per field
+func (m *Metrics) Send(points []timeseries.Point) error {
+	return m.client.post(MetricsPath, metricsRequest{ResourceMetrics: []resourceMetrics{{
+		Resource:     webmonitorResource,
+		ScopeMetrics: []scopeMetrics{{Scope: webmonitorScope, Metrics: gauges(points)}},
+	}}})
+}
+
+// Close does nothing, as the requests are not kept open
+func (m *Metrics) Close() error {
+	return nil
+}
+
+// gauges returns the gauges of the fields of points, sorted by name
+func gauges(points []timeseries.Point) []metric {
+	metrics := make(map[string]*metric)
+	for _, point := range points {
+		attributes := tagAttributes(point.Tags)
+		for field, value := range point.Fields {
+			name, unit := metricName(point.Measurement, field)
+			if metrics[name] == nil {
+				metrics[name] = &metric{Name: name, Unit: unit}
+			}
+			dataPoint := dataPoint{Attributes: attributes, TimeUnixNano: unixNano(point.Time)}
+			switch value := value.(type) {
+			case int:
+				asInt := strconv.Itoa(value)
+				dataPoint.AsInt = &asInt
+			case float64:
+				dataPoint.AsDouble = &value
+			}
+			metrics[name].Gauge.DataPoints = append(metrics[name].Gauge.DataPoints, dataPoint)
+		}
+	}
+	sorted := make([]metric, 0, len(metrics))
+	for _, metric := range metrics {
+		sorted = append(sorted, *metric)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// metricName returns the OTLP name and unit of a field, such as webmonitor.stats.p95 in ms for the p95_ms field of the statistics
+func metricName(measurement string, field string) (string, string) {
+	unit := ""
+	if strings.HasSuffix(field, "_ms") {
+		field = strings.TrimSuffix(field, "_ms")
+		unit = "ms"
+	}
+	return strings.Replace(measurement, "_", ".", 1) + "." + field, unit
+}
@@ -0,0 +1,206 @@
+package otlp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/timeseries"
+)
+
+// Kinds of the spans
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+// Status codes of the spans
+const (
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Span is an OTLP span, whose ids are in hexadecimal as in the JSON encoding
+type Span struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+	// Id of the parent span, empty for the span of a check
+	ParentSpanID string     `json:"parentSpanId,omitempty"`
+	Name         string     `json:"name"`
+	Kind         int        `json:"kind"`
+	Start        string     `json:"startTimeUnixNano"`
+	End          string     `json:"endTimeUnixNano"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+	Status       *status    `json:"status,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type tracesRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []Span `json:"spans"`
+}
+
+// CheckSpans returns the span of a traced check, whose id was sent to the website in its traceparent header, and a
+// child span per phase of its request. Its URL label and the reason of the check should hold no secret.
+func CheckSpans(label string, tags map[string]string, stats monitor.CheckStats) []Span {
+	trace := stats.Trace
+	traceID := hex.EncodeToString(trace.TraceID[:])
+	spanID := hex.EncodeToString(trace.SpanID[:])
+	attributes := append([]keyValue{
+		stringAttribute("http.request.method", "GET"),
+		stringAttribute("url.full", label),
+		intAttribute("http.response.status_code", stats.StatusCode),
+	}, tagAttributes(tags)...)
+	check := Span{TraceID: traceID, SpanID: spanID, Name: "GET", Kind: KindClient, Start: unixNano(stats.Time), End: unixNano(trace.End), Attributes: attributes, Status: &status{Code: StatusOK}}
+	if stats.StatusCode != 200 {
+		check.Status = &status{Code: StatusError, Message: stats.Reason}
+	}
+	spans := []Span{check}
+	for _, phase := range trace.Phases {
+		spans = append(spans, Span{TraceID: traceID, SpanID: newSpanID(), ParentSpanID: spanID, Name: phase.Name, Kind: KindInternal, Start: unixNano(phase.Start), End: unixNano(phase.End)})
+	}
+	return spans
+}
+
+// newSpanID returns a random span id in hexadecimal
+func newSpanID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// TraceExporter sends spans to an OTLP collector in batches from a goroutine. Spans are buffered while the collector
+// fails, up to a buffer size beyond which the oldest ones are dropped, as points are by the timeseries exporters.
+type TraceExporter struct {
+	name       string
+	client     *Client
+	batchSize  int
+	bufferSize int
+	failures   chan<- timeseries.Failure
+	mutex      sync.Mutex
+	// Spans waiting to be sent, oldest first
+	pending []Span
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewTraceExporter returns a new TraceExporter of a collector client and starts it. Its failures and recoveries are
+// sent on the failures channel, if any, without blocking.
+func NewTraceExporter(name string, client *Client, batchSize int, bufferSize int, flushInterval time.Duration, failures chan<- timeseries.Failure) *TraceExporter {
+	if bufferSize < batchSize {
+		bufferSize = batchSize
+	}
+	e := &TraceExporter{
+		name:       name,
+		client:     client,
+		batchSize:  batchSize,
+		bufferSize: bufferSize,
+		failures:   failures,
+		full:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go e.run(flushInterval)
+	return e
+}
+
+// Add queues spans to be sent with the next batch, without blocking
+func (e *TraceExporter) Add(spans ...Span) {
+	e.mutex.Lock()
+	e.pending = append(e.pending, spans...)
+	e.trim()
+	full := len(e.pending) >= e.batchSize
+	e.mutex.Unlock()
+	if full {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close sends the pending spans a last time
+func (e *TraceExporter) Close() error {
+	close(e.done)
+	<-e.stopped
+	return nil
+}
+
+// run flushes the pending spans when a batch is full or at each flush interval, until the exporter is closed
+func (e *TraceExporter) run(flushInterval time.Duration) {
+	defer close(e.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-e.full:
+		case <-ticker.C:
+		case <-e.done:
+			e.flush()
+			return
+		}
+		err := e.flush()
+		if failing != (err != nil) {
+			failing = err != nil
+			if e.failures != nil {
+				select {
+				case e.failures <- timeseries.Failure{Exporter: e.name, Err: err}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// flush sends the pending spans in batches, and stops at the first failure, keeping the batch for the next flush
+func (e *TraceExporter) flush() error {
+	for {
+		e.mutex.Lock()
+		size := len(e.pending)
+		if size > e.batchSize {
+			size = e.batchSize
+		}
+		batch := e.pending[:size:size]
+		e.pending = e.pending[size:]
+		e.mutex.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+		err := e.client.post(TracesPath, tracesRequest{ResourceSpans: []resourceSpans{{
+			Resource:   webmonitorResource,
+			ScopeSpans: []scopeSpans{{Scope: webmonitorScope, Spans: batch}},
+		}}})
+		if err != nil {
+			// Putting the batch back before the spans added meanwhile
+			e.mutex.Lock()
+			e.pending = append(batch, e.pending...)
+			e.trim()
+			e.mutex.Unlock()
+			return err
+		}
+	}
+}
+
+// trim drops the oldest pending spans beyond the buffer size. The mutex should be held.
+func (e *TraceExporter) trim() {
+	if len(e.pending) > e.bufferSize {
+		e.pending = append([]Span(nil), e.pending[len(e.pending)-e.bufferSize:]...)
+	}
+}
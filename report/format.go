@@ -0,0 +1,166 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// headers are the column names of the table, csv and markdown formats
+var headers = []string{"Website", "Checks", "Uptime", "Incidents", "Downtime", "MTTR", "MTBF", "p50 (ms)", "p90 (ms)", "p95 (ms)", "p99 (ms)", "Apdex"}
+
+// jsonSummary is the JSON representation of a Summary. Durations are in seconds, percentiles are null without checks.
+type jsonSummary struct {
+	URL       string   `json:"url"`
+	Checks    int      `json:"checks"`
+	Uptime    float64  `json:"uptime"`
+	Incidents int      `json:"incidents"`
+	Downtime  float64  `json:"downtimeSeconds"`
+	MTTR      float64  `json:"mttrSeconds"`
+	MTBF      float64  `json:"mtbfSeconds"`
+	P50       *float64 `json:"p50"`
+	P90       *float64 `json:"p90"`
+	P95       *float64 `json:"p95"`
+	P99       *float64 `json:"p99"`
+	Apdex     *float64 `json:"apdex"`
+}
+
+// Write writes the summaries in a format : table, csv, json or markdown
+func Write(w io.Writer, format string, summaries []Summary) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, summaries)
+	case "json":
+		return writeJSON(w, summaries)
+	case "markdown":
+		return writeMarkdown(w, summaries)
+	case "table":
+		return writeTable(w, summaries)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// writeTable writes the summaries as an aligned text table
+func writeTable(w io.Writer, summaries []Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, summary := range summaries {
+		fmt.Fprintln(tw, strings.Join(humanRow(summary), "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeMarkdown writes the summaries as a markdown table
+func writeMarkdown(w io.Writer, summaries []Summary) error {
+	fmt.Fprintf(w, "| %s |\n", strings.Join(headers, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(headers)))
+	for _, summary := range summaries {
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(humanRow(summary), " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes the summaries as CSV. Durations are in seconds.
+func writeCSV(w io.Writer, summaries []Summary) error {
+	cw := csv.NewWriter(w)
+	cw.Write(headers)
+	for _, summary := range summaries {
+		cw.Write([]string{
+			summary.URL,
+			fmt.Sprint(summary.Checks),
+			fmt.Sprintf("%.5f", summary.Uptime),
+			fmt.Sprint(summary.Incidents),
+			fmt.Sprintf("%.0f", summary.Downtime.Seconds()),
+			fmt.Sprintf("%.0f", summary.MTTR.Seconds()),
+			fmt.Sprintf("%.0f", summary.MTBF.Seconds()),
+			formatPercentile(summary.P50),
+			formatPercentile(summary.P90),
+			formatPercentile(summary.P95),
+			formatPercentile(summary.P99),
+			formatApdex(summary.Apdex),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes the summaries as a JSON array
+func writeJSON(w io.Writer, summaries []Summary) error {
+	jsonSummaries := make([]jsonSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		jsonSummaries = append(jsonSummaries, jsonSummary{
+			URL:       summary.URL,
+			Checks:    summary.Checks,
+			Uptime:    summary.Uptime,
+			Incidents: summary.Incidents,
+			Downtime:  summary.Downtime.Seconds(),
+			MTTR:      summary.MTTR.Seconds(),
+			MTBF:      summary.MTBF.Seconds(),
+			P50:       nullable(summary.P50),
+			P90:       nullable(summary.P90),
+			P95:       nullable(summary.P95),
+			P99:       nullable(summary.P99),
+			Apdex:     nullable(summary.Apdex),
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonSummaries)
+}
+
+// humanRow returns the human readable cells of a summary
+func humanRow(summary Summary) []string {
+	return []string{
+		summary.URL,
+		fmt.Sprint(summary.Checks),
+		fmt.Sprintf("%.3f%%", summary.Uptime*100.0),
+		fmt.Sprint(summary.Incidents),
+		formatDuration(summary.Downtime),
+		formatDuration(summary.MTTR),
+		formatDuration(summary.MTBF),
+		formatPercentile(summary.P50),
+		formatPercentile(summary.P90),
+		formatPercentile(summary.P95),
+		formatPercentile(summary.P99),
+		formatApdex(summary.Apdex),
+	}
+}
+
+// formatDuration rounds a duration to the second, or returns - for an empty duration
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// formatPercentile formats a percentile, or returns - when there are no checks
+func formatPercentile(p float64) string {
+	if math.IsNaN(p) {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", p)
+}
+
+// formatApdex formats an Apdex score, or returns - when there are no checks
+func formatApdex(apdex float64) string {
+	if math.IsNaN(apdex) {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", apdex)
+}
+
+// nullable returns a pointer to a value, or nil if it is NaN
+func nullable(value float64) *float64 {
+	if math.IsNaN(value) {
+		return nil
+	}
+	return &value
+}
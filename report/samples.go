@@ -0,0 +1,63 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// sampleHeaders are the column names of the csv format of the samples
+var sampleHeaders = []string{"url", "time", "response_time_ms", "status_code", "reason"}
+
+// jsonSample is the JSON representation of a sample
+type jsonSample struct {
+	URL          string    `json:"url"`
+	Time         time.Time `json:"time"`
+	ResponseTime int       `json:"responseTimeMs"`
+	StatusCode   int       `json:"statusCode"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// RecordSamples returns the samples of records of the history
+func RecordSamples(records []history.Record) []statistics.Sample {
+	samples := make([]statistics.Sample, 0, len(records))
+	for _, record := range records {
+		samples = append(samples, statistics.Sample{Time: record.Time, ResponseTime: record.ResponseTime, StatusCode: record.StatusCode, Reason: record.Reason})
+	}
+	return samples
+}
+
+// WriteSamples writes the samples of a website in a format : csv or json. Times are in RFC 3339 with milliseconds.
+func WriteSamples(w io.Writer, format string, url string, samples []statistics.Sample) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(sampleHeaders)
+		for _, sample := range samples {
+			cw.Write([]string{
+				url,
+				sample.Time.Format("2006-01-02T15:04:05.000Z07:00"),
+				strconv.Itoa(sample.ResponseTime),
+				strconv.Itoa(sample.StatusCode),
+				sample.Reason,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		jsonSamples := make([]jsonSample, 0, len(samples))
+		for _, sample := range samples {
+			jsonSamples = append(jsonSamples, jsonSample{URL: url, Time: sample.Time.Round(time.Millisecond), ResponseTime: sample.ResponseTime, StatusCode: sample.StatusCode, Reason: sample.Reason})
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(jsonSamples)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
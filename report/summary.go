@@ -0,0 +1,134 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// Summary is the availability report of a website over a period
+type Summary struct {
+	URL    string
+	Checks int
+	// Fraction of successful checks
+	Uptime float64
+	// Number of incidents overlapping the period, and their downtime within it
+	Incidents int
+	Downtime  time.Duration
+	// Mean time to recovery and mean time between failures, 0 without incidents
+	MTTR time.Duration
+	MTBF time.Duration
+	// Response time percentiles, in ms
+	P50 float64
+	P90 float64
+	P95 float64
+	P99 float64
+	// Apdex score, NaN without checks
+	Apdex float64
+}
+
+// Summarize computes the availability report of a website over [from, to), from its raw records, its rollups and its
+// recorded incidents. Within a rollup, the fastest response times are assumed to be the successful ones to compute the
+// Apdex score. Incidents still ongoing last up to the end of the period.
+func Summarize(url string, records []history.Record, rollups []history.Rollup, urlIncidents []incidents.Incident, from time.Time, to time.Time, apdexTarget int) Summary {
+	summary := Summary{URL: url}
+	var responseTimes statistics.Histogram
+	successes := 0
+	// Satisfied and tolerating checks
+	var satisfied, tolerating int
+	for _, rollup := range rollups {
+		summary.Checks += rollup.Count
+		successes += rollup.StatusCodeCount[200]
+		responseTimes.Merge(&rollup.ResponseTimes)
+		rollupSatisfied := minInt(rollup.StatusCodeCount[200], rollup.ResponseTimes.CountAtMost(apdexTarget))
+		satisfied += rollupSatisfied
+		tolerating += minInt(rollup.StatusCodeCount[200], rollup.ResponseTimes.CountAtMost(4*apdexTarget)) - rollupSatisfied
+	}
+	for _, record := range records {
+		summary.Checks++
+		if record.StatusCode == 200 {
+			successes++
+			if record.ResponseTime <= apdexTarget {
+				satisfied++
+			} else if record.ResponseTime <= 4*apdexTarget {
+				tolerating++
+			}
+		}
+		responseTimes.Record(record.ResponseTime)
+	}
+	summary.Apdex = math.NaN()
+	if summary.Checks > 0 {
+		summary.Uptime = float64(successes) / float64(summary.Checks)
+		summary.Apdex = (float64(satisfied) + float64(tolerating)/2) / float64(summary.Checks)
+	}
+	for _, incident := range urlIncidents {
+		if !incident.Ongoing() && incident.End.Before(from) {
+			continue
+		}
+		// Only the downtime within the period is counted
+		start, end := incident.Start, incident.End
+		if start.Before(from) {
+			start = from
+		}
+		if incident.Ongoing() || end.After(to) {
+			end = to
+		}
+		summary.Incidents++
+		summary.Downtime += end.Sub(start)
+	}
+	if summary.Incidents > 0 {
+		summary.MTTR = summary.Downtime / time.Duration(summary.Incidents)
+		summary.MTBF = (to.Sub(from) - summary.Downtime) / time.Duration(summary.Incidents)
+	}
+	summary.P50 = responseTimes.Percentile(50)
+	summary.P90 = responseTimes.Percentile(90)
+	summary.P95 = responseTimes.Percentile(95)
+	summary.P99 = responseTimes.Percentile(99)
+	return summary
+}
+
+// minInt returns the smallest of two integers
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Build computes the availability report of the given websites over [from, to) from a history. Every URL of the history is reported if urls is empty.
+// The Apdex target of a website is read from apdexTargets, defaulting to apdexTarget.
+func Build(store *history.Store, urls []string, from time.Time, to time.Time, apdexTargets map[string]int, apdexTarget int) ([]Summary, error) {
+	if len(urls) == 0 {
+		var err error
+		if urls, err = store.URLs(); err != nil {
+			return nil, err
+		}
+		sort.Strings(urls)
+	}
+	summaries := make([]Summary, 0, len(urls))
+	for _, url := range urls {
+		records, err := store.Records(url, from, to)
+		if err != nil {
+			return nil, err
+		}
+		rollups, err := store.Rollups(url, from, to)
+		if err != nil {
+			return nil, err
+		}
+		// Incidents which started before the period may overlap it
+		urlIncidents, err := store.Incidents(url, time.Time{}, to)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := apdexTargets[url]
+		if !ok {
+			target = apdexTarget
+		}
+		summaries = append(summaries, Summarize(url, records, rollups, urlIncidents, from, to, target))
+	}
+	return summaries, nil
+}
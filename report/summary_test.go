@@ -0,0 +1,103 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+)
+
+func TestSummarize(t *testing.T) {
+	// Test the availability figures of a website going down twice over an hour
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	url := "https://example.com"
+	statusCodes := map[time.Duration]int{
+		0:                500,
+		1 * time.Minute:  200,
+		10 * time.Minute: 200,
+		20 * time.Minute: 408,
+		22 * time.Minute: 500,
+		25 * time.Minute: 200,
+		50 * time.Minute: 200,
+	}
+	records := make([]history.Record, 0)
+	for elapsed, statusCode := range statusCodes {
+		records = append(records, history.Record{URL: url, Time: from.Add(elapsed), ResponseTime: 50, StatusCode: statusCode})
+	}
+	urlIncidents := []incidents.Incident{
+		// Ended before the period
+		{URL: url, Start: from.Add(-time.Hour), End: from.Add(-50 * time.Minute)},
+		// Started before the period, only its last minute counts
+		{URL: url, Start: from.Add(-10 * time.Minute), End: from.Add(time.Minute)},
+		{URL: url, Start: from.Add(20 * time.Minute), End: from.Add(25 * time.Minute)},
+	}
+	summary := Summarize(url, records, nil, urlIncidents, from, to, 500)
+
+	if summary.Checks != 7 {
+		t.Errorf("Checks == %v, want %v", summary.Checks, 7)
+	}
+	if summary.Incidents != 2 {
+		t.Errorf("Incidents == %v, want %v", summary.Incidents, 2)
+	}
+	if summary.Downtime != 6*time.Minute {
+		t.Errorf("Downtime == %v, want %v", summary.Downtime, 6*time.Minute)
+	}
+	if summary.MTTR != 3*time.Minute {
+		t.Errorf("MTTR == %v, want %v", summary.MTTR, 3*time.Minute)
+	}
+	if summary.MTBF != 27*time.Minute {
+		t.Errorf("MTBF == %v, want %v", summary.MTBF, 27*time.Minute)
+	}
+	// 4 satisfied checks out of 7
+	if summary.Apdex != 4.0/7.0 {
+		t.Errorf("Apdex == %v, want %v", summary.Apdex, 4.0/7.0)
+	}
+	if summary.P95 != 50 {
+		t.Errorf("P95 == %v, want %v", summary.P95, 50)
+	}
+}
+
+func TestSummarizeOngoing(t *testing.T) {
+	// An ongoing incident lasts up to the end of the period
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	summary := Summarize("https://example.com", nil, nil, []incidents.Incident{{Start: from.Add(50 * time.Minute)}}, from, to, 500)
+	if summary.Incidents != 1 || summary.Downtime != 10*time.Minute {
+		t.Errorf("Incidents, Downtime == %v, %v, want 1, 10m", summary.Incidents, summary.Downtime)
+	}
+}
+
+func TestWriteSamples(t *testing.T) {
+	// Test the csv and json exports of the samples of a website
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := RecordSamples([]history.Record{
+		{Time: start, ResponseTime: 120, StatusCode: 200},
+		{Time: start.Add(5 * time.Second), ResponseTime: 5000, StatusCode: 408, Reason: "timeout, no response"},
+	})
+	var buffer bytes.Buffer
+	if err := WriteSamples(&buffer, "csv", "https://example.com", samples); err != nil {
+		t.Fatalf("WriteSamples returned %v", err)
+	}
+	want := "url,time,response_time_ms,status_code,reason\n" +
+		"https://example.com,2020-01-01T12:00:00.000Z,120,200,\n" +
+		"https://example.com,2020-01-01T12:00:05.000Z,5000,408,\"timeout, no response\"\n"
+	if buffer.String() != want {
+		t.Errorf("csv == %q, want %q", buffer.String(), want)
+	}
+
+	buffer.Reset()
+	if err := WriteSamples(&buffer, "json", "https://example.com", samples); err != nil {
+		t.Fatalf("WriteSamples returned %v", err)
+	}
+	var decoded []jsonSample
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil || len(decoded) != 2 || decoded[1].StatusCode != 408 || decoded[1].Reason != "timeout, no response" || !decoded[1].Time.Equal(start.Add(5*time.Second)) {
+		t.Errorf("json == %s, want the 2 samples", buffer.String())
+	}
+	if err := WriteSamples(&buffer, "xml", "https://example.com", samples); err == nil {
+		t.Errorf("WriteSamples in xml returned no error")
+	}
+}
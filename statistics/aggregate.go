@@ -0,0 +1,95 @@
+package statistics
+
+import "time"
+
+// rawWindow is the duration over which a Statistic keeps every check. The older checks of longer windows are aggregated
+// over fixed steps, so that the memory of a window is bounded whatever its duration.
+const rawWindow = time.Hour
+
+// maxAggregates is the number of steps a long window is at most aggregated over, but for windows longer than 250 days
+const maxAggregates = 250
+
+// aggregateSteps are the durations checks can be aggregated over, the shortest fitting being used. Each of them divides
+// the longer ones, as well as the steps of the timeline which are no shorter, so that a timeline column holds whole steps.
+var aggregateSteps = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// aggregate is the checks of a Statistic within a step of time, older than the raw window
+type aggregate struct {
+	// Start of the step, and time of its most recent check
+	start             time.Time
+	last              time.Time
+	checks            int
+	totalResponseTime int
+	minResponseTime   int
+	maxResponseTime   int
+	statusCodeCount   map[int]int
+	satisfied         int
+	tolerating        int
+	responseTimes     Histogram
+}
+
+// aggregateStep returns the step the checks of a window older than the raw window are aggregated over, 0 if every check is kept
+func aggregateStep(window time.Duration) time.Duration {
+	if window <= rawWindow {
+		return 0
+	}
+	for _, step := range aggregateSteps {
+		if step*maxAggregates >= window {
+			return step
+		}
+	}
+	return aggregateSteps[len(aggregateSteps)-1]
+}
+
+// AggregateStep returns the step the checks older than an hour are aggregated over, 0 if the Statistic keeps every check
+func (s *Statistic) AggregateStep() time.Duration {
+	return s.step
+}
+
+// aggregate adds a check older than the raw window to the aggregate of its step. Checks are usually aggregated in order.
+func (s *Statistic) aggregate(i item) {
+	start := i.Time.Truncate(s.step)
+	index := len(s.aggregates)
+	for index > 0 && s.aggregates[index-1].start.After(start) {
+		index--
+	}
+	if index == 0 || !s.aggregates[index-1].start.Equal(start) {
+		s.aggregates = append(s.aggregates, aggregate{})
+		copy(s.aggregates[index+1:], s.aggregates[index:])
+		s.aggregates[index] = aggregate{start: start, minResponseTime: i.ResponseTime, statusCodeCount: make(map[int]int)}
+		index++
+	}
+	a := &s.aggregates[index-1]
+	if i.Time.After(a.last) {
+		a.last = i.Time
+	}
+	a.checks++
+	a.totalResponseTime += i.ResponseTime
+	if i.ResponseTime < a.minResponseTime {
+		a.minResponseTime = i.ResponseTime
+	}
+	if i.ResponseTime > a.maxResponseTime {
+		a.maxResponseTime = i.ResponseTime
+	}
+	a.statusCodeCount[i.Statuscode]++
+	a.responseTimes.Record(i.ResponseTime)
+	if i.Statuscode == 200 && i.ResponseTime <= s.apdexTarget {
+		a.satisfied++
+	} else if i.Statuscode == 200 && i.ResponseTime <= 4*s.apdexTarget {
+		a.tolerating++
+	}
+}
+
+// evictAggregate removes the oldest aggregate from the window
+func (s *Statistic) evictAggregate() {
+	a := &s.aggregates[0]
+	s.checks -= a.checks
+	s.totalResponseTime -= a.totalResponseTime
+	for statusCode, count := range a.statusCodeCount {
+		s.statusCodeCount[statusCode] -= count
+	}
+	s.responseTimes.Subtract(&a.responseTimes)
+	s.satisfied -= a.satisfied
+	s.tolerating -= a.tolerating
+	s.aggregates = s.aggregates[1:]
+}
@@ -0,0 +1,81 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatisticAggregates(t *testing.T) {
+	// Test if the checks of a long window older than an hour are aggregated, without changing its statistics
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(3 * time.Hour) }
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(24*time.Hour, 500)
+	if s.AggregateStep() != 15*time.Minute {
+		t.Errorf("AggregateStep() == %v, want 15m0s", s.AggregateStep())
+	}
+	// A check per minute for 3 hours, every tenth one failing
+	var total int
+	var histogram Histogram
+	for i := 0; i <= 180; i++ {
+		statusCode := 200
+		if i%10 == 0 {
+			statusCode = 500
+		}
+		s.AddRecord(start.Add(time.Duration(i)*time.Minute), i*10, statusCode)
+		total += i * 10
+		histogram.Record(i * 10)
+	}
+	if got := s.Average(); got != float64(total)/181 {
+		t.Errorf("Average() == %v, want %v", got, float64(total)/181)
+	}
+	if got := s.Availability(); got != 162.0/181 {
+		t.Errorf("Availability() == %v, want %v", got, 162.0/181)
+	}
+	if got, want := s.Percentile(90), histogram.Percentile(90); got != want {
+		t.Errorf("Percentile(90) == %v, want %v", got, want)
+	}
+	if s.MinResponseTime() != 0 || s.MaxResponseTime() != 1800 {
+		t.Errorf("MinResponseTime(), MaxResponseTime() == %v, %v, want 0, 1800", s.MinResponseTime(), s.MaxResponseTime())
+	}
+	// Only the last hour is kept check by check
+	if len(s.Samples()) != 60 || len(s.aggregates) != 9 {
+		t.Errorf("%d samples and %d aggregates, want 60 and 9", len(s.Samples()), len(s.aggregates))
+	}
+	for hour, bucket := range s.Buckets(start, time.Hour, 3, []int{1000, 2000}) {
+		if bucket.Checks != 60 || bucket.Failures != 6 || bucket.Counts[0]+bucket.Counts[1] != 60 {
+			t.Errorf("Buckets()[%d] == %+v, want 60 checks and 6 failures", hour, bucket)
+		}
+	}
+
+	// Aggregates are evicted once their most recent check is older than the window
+	now = func() time.Time { return start.Add(24*time.Hour + 30*time.Minute) }
+	if got := s.Snapshot().Checks; got != 151 {
+		t.Errorf("Snapshot().Checks == %v, want 151", got)
+	}
+	if s.MinResponseTime() != 300 {
+		t.Errorf("MinResponseTime() == %v, want 300", s.MinResponseTime())
+	}
+	now = func() time.Time { return start.Add(48 * time.Hour) }
+	if snapshot := s.Snapshot(); snapshot.Checks != 0 || len(s.aggregates) != 0 {
+		t.Errorf("Snapshot() == %+v, want no check", snapshot)
+	}
+}
+
+func TestStatisticAggregatesBounded(t *testing.T) {
+	// Test if the memory of a long window is bounded whatever the number of its checks
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+	s := NewStatistic(30*24*time.Hour, 500)
+	for i := 0; i < 10*24*60; i++ {
+		s.AddRecord(start.Add(time.Duration(i)*time.Minute), 100, 200)
+	}
+	now = func() time.Time { return start.Add(10 * 24 * time.Hour) }
+	if s.recentStats.length() > 60 || len(s.aggregates) > maxAggregates {
+		t.Errorf("%d checks and %d aggregates kept, want at most 60 and %d", s.recentStats.length(), len(s.aggregates), maxAggregates)
+	}
+	if got := s.Snapshot().Checks; got != 10*24*60 {
+		t.Errorf("Snapshot().Checks == %v, want %v", got, 10*24*60)
+	}
+}
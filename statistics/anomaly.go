@@ -0,0 +1,97 @@
+package statistics
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// anomalyWarmup is the number of successful checks an AnomalyDetector learns from before flagging deviations
+const anomalyWarmup = 20
+
+// relearnRate is the fraction of the weight of a check with which the mean learns deviating checks once anomalous
+const relearnRate = 0.25
+
+// minDeviation is the smallest standard deviation in ms, so that a steady baseline does not flag every small change
+const minDeviation = 1.0
+
+// AnomalyDetector learns the usual response time of a website with exponentially weighted moving averages of its mean
+// and variance, and flags response times deviating from the mean by more than a number of standard deviations for a
+// sustained period. Deviating checks are left out of the baseline, so that a short anomaly does not become the norm. Once
+// anomalous, the mean learns them with a lower weight, so that a lasting change of the response time eventually becomes
+// the new baseline rather than keeping the anomaly alert firing. Their variance is left out, as it would mostly measure
+// the change itself and widen the tolerated deviation.
+type AnomalyDetector struct {
+	mutex sync.Mutex
+	// Weight of a new check in the moving averages, between 0 and 1
+	alpha float64
+	// Number of standard deviations from which a response time deviates
+	Sigmas float64
+	// Duration for which response times should keep deviating to be anomalous
+	Sustain  time.Duration
+	count    int
+	mean     float64
+	variance float64
+	// Time of the first check of the ongoing deviation, zero without deviation
+	since     time.Time
+	anomalous bool
+}
+
+// NewAnomalyDetector returns a new AnomalyDetector
+func NewAnomalyDetector(alpha float64, sigmas float64, sustain time.Duration) *AnomalyDetector {
+	return &AnomalyDetector{alpha: alpha, Sigmas: sigmas, Sustain: sustain}
+}
+
+// AddRecord adds a check to the AnomalyDetector. Failed checks are ignored, as they are covered by the availability alerts.
+func (d *AnomalyDetector) AddRecord(t time.Time, responseTime int, statuscode int) {
+	if statuscode != 200 {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	value := float64(responseTime)
+	if d.count >= anomalyWarmup && math.Abs(value-d.mean) > d.Sigmas*d.deviation() {
+		if d.since.IsZero() {
+			d.since = t
+		}
+		if d.anomalous {
+			d.mean += relearnRate * d.alpha * (value - d.mean)
+		} else if t.Sub(d.since) >= d.Sustain {
+			d.anomalous = true
+		}
+		return
+	}
+	d.since = time.Time{}
+	d.anomalous = false
+	// Updating the moving averages
+	d.count++
+	if d.count == 1 {
+		d.mean = value
+		return
+	}
+	difference := value - d.mean
+	d.mean += d.alpha * difference
+	d.variance = (1 - d.alpha) * (d.variance + d.alpha*difference*difference)
+}
+
+// deviation returns the standard deviation of the baseline
+func (d *AnomalyDetector) deviation() float64 {
+	return math.Max(math.Sqrt(d.variance), minDeviation)
+}
+
+// Anomalous returns whether the response times have been deviating from the baseline for the sustained period
+func (d *AnomalyDetector) Anomalous() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.anomalous
+}
+
+// Baseline returns the mean and standard deviation of the usual response time in ms, NaN before any successful check
+func (d *AnomalyDetector) Baseline() (float64, float64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.count == 0 {
+		return math.NaN(), math.NaN()
+	}
+	return d.mean, d.deviation()
+}
@@ -0,0 +1,111 @@
+package statistics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	// Checks every 5 seconds, around 100ms
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	random := rand.New(rand.NewSource(1))
+	d := NewAnomalyDetector(0.05, 3, 30*time.Second)
+	check := 0
+	add := func(responseTime int, statuscode int) {
+		d.AddRecord(start.Add(time.Duration(check)*5*time.Second), responseTime, statuscode)
+		check++
+	}
+	for i := 0; i < 200; i++ {
+		add(90+random.Intn(21), 200)
+		if d.Anomalous() {
+			t.Fatalf("Anomalous() == true on the usual response times, check %d", check)
+		}
+	}
+	if mean, deviation := d.Baseline(); math.Abs(mean-100) > 5 || deviation > 10 {
+		t.Errorf("Baseline() == %v, %v, want about 100, 6", mean, deviation)
+	}
+
+	// A short spike is not sustained
+	add(400, 200)
+	add(400, 200)
+	add(100, 200)
+	if d.Anomalous() {
+		t.Errorf("Anomalous() == true after a short spike")
+	}
+	// Neither are failed checks
+	for i := 0; i < 10; i++ {
+		add(5000, 408)
+	}
+	if d.Anomalous() {
+		t.Errorf("Anomalous() == true after failed checks")
+	}
+
+	// A sustained slowdown is anomalous once it lasted 30 seconds
+	for i := 0; i < 6; i++ {
+		add(300, 200)
+		if d.Anomalous() {
+			t.Fatalf("Anomalous() == true after %d slow checks, want false", i+1)
+		}
+	}
+	add(300, 200)
+	if !d.Anomalous() {
+		t.Errorf("Anomalous() == false after a 30 seconds slowdown")
+	}
+	// It does not shift the baseline
+	if mean, _ := d.Baseline(); math.Abs(mean-100) > 5 {
+		t.Errorf("Baseline() mean == %v after the slowdown, want about 100", mean)
+	}
+	add(100, 200)
+	if d.Anomalous() {
+		t.Errorf("Anomalous() == true once the response times are back to normal")
+	}
+}
+
+func TestAnomalyDetectorDrift(t *testing.T) {
+	// A slow drift, such as the daily variation of a website, is learnt rather than flagged
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewAnomalyDetector(0.05, 3, time.Minute)
+	for i := 0; i < 17280; i++ {
+		t0 := start.Add(time.Duration(i) * 5 * time.Second)
+		// Between 100 and 300ms along the day, with some noise
+		responseTime := 200 + 100*math.Sin(2*math.Pi*float64(i)/17280) + float64(i%7)
+		d.AddRecord(t0, int(responseTime), 200)
+		if d.Anomalous() {
+			t.Fatalf("Anomalous() == true at %v", t0)
+		}
+	}
+}
+
+func TestAnomalyDetectorStep(t *testing.T) {
+	// A lasting step change, such as a slower release, is flagged and then learnt as the new baseline
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	random := rand.New(rand.NewSource(1))
+	d := NewAnomalyDetector(0.05, 3, time.Minute)
+	for i := 0; i < 200; i++ {
+		d.AddRecord(start.Add(time.Duration(i)*5*time.Second), 90+random.Intn(21), 200)
+	}
+	flagged, resolved := -1, -1
+	for i := 200; i < 920; i++ {
+		d.AddRecord(start.Add(time.Duration(i)*5*time.Second), 290+random.Intn(21), 200)
+		if d.Anomalous() && flagged < 0 {
+			flagged = i
+		}
+		if !d.Anomalous() && flagged >= 0 && resolved < 0 {
+			resolved = i
+		}
+	}
+	if flagged != 212 {
+		t.Errorf("step flagged at check %d, want 212 after the sustained minute", flagged)
+	}
+	// It stays anomalous for a while, as the mean learns the deviating checks slowly
+	if resolved < 0 {
+		t.Fatalf("Anomalous() == true an hour after the step, want it learnt")
+	} else if resolved-flagged < 120 {
+		t.Errorf("step learnt after %d checks, want at least 10 minutes of checks", resolved-flagged)
+	}
+	if mean, _ := d.Baseline(); math.Abs(mean-300) > 10 {
+		t.Errorf("Baseline() mean == %v an hour after the step, want about 300", mean)
+	}
+}
@@ -0,0 +1,100 @@
+package statistics
+
+import (
+	"sort"
+	"time"
+)
+
+// intervalChecks is the number of most recent checks the usual interval between checks is measured on
+const intervalChecks = 16
+
+// Bucket is the checks of a Statistic within a step of time
+type Bucket struct {
+	Checks int
+	// Failed checks, and successful checks slower than the Apdex target
+	Failures int
+	Slow     int
+	// Number of checks up to each response time bound in ms and above the previous one, the last one counting the slower checks too
+	Counts []int
+}
+
+// Buckets returns the checks of a number of consecutive steps of time from start, counted by response time bounds.
+// The checks are counted in place rather than copied, so that long windows stay cheap to draw. Aggregated checks are
+// counted in the step of their aggregate's start, which should be a multiple of the aggregate step.
+func (s *Statistic) Buckets(start time.Time, step time.Duration, count int, bounds []int) []Bucket {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	buckets := make([]Bucket, count)
+	for column := range buckets {
+		buckets[column].Counts = make([]int, len(bounds))
+	}
+	for index := range s.aggregates {
+		a := &s.aggregates[index]
+		column := int(a.start.Sub(start) / step)
+		if a.start.Before(start) || column >= count {
+			continue
+		}
+		bucket := &buckets[column]
+		bucket.Checks += a.checks
+		bucket.Failures += a.checks - a.statusCodeCount[200]
+		bucket.Slow += a.statusCodeCount[200] - a.satisfied
+		below := 0
+		for row, bound := range bounds {
+			atMost := a.responseTimes.CountAtMost(bound)
+			if row == len(bounds)-1 {
+				atMost = a.checks
+			}
+			bucket.Counts[row] += atMost - below
+			below = atMost
+		}
+	}
+	queue := s.recentStats
+	// Items are queued in the order of their checks, even when they complete in another order
+	first := sort.Search(queue.length(), func(index int) bool {
+		return !queue.at(index).Time.Before(start)
+	})
+	for index := first; index < queue.length(); index++ {
+		item := queue.at(index)
+		column := int(item.Time.Sub(start) / step)
+		if item.Time.Before(start) || column >= count {
+			continue
+		}
+		bucket := &buckets[column]
+		bucket.Checks++
+		if item.Statuscode != 200 {
+			bucket.Failures++
+		} else if item.ResponseTime > s.apdexTarget {
+			bucket.Slow++
+		}
+		if len(bounds) > 0 {
+			row := sort.SearchInts(bounds, item.ResponseTime)
+			if row == len(bounds) {
+				row--
+			}
+			bucket.Counts[row]++
+		}
+	}
+	return buckets
+}
+
+// CheckInterval returns the median interval between the most recent checks, 0 with fewer than 2 checks
+func (s *Statistic) CheckInterval() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	queue := s.recentStats
+	first := queue.length() - intervalChecks - 1
+	if first < 0 {
+		first = 0
+	}
+	if queue.length()-first < 2 {
+		return 0
+	}
+	gaps := make([]time.Duration, 0, queue.length()-first-1)
+	for index := first + 1; index < queue.length(); index++ {
+		gaps = append(gaps, queue.at(index).Time.Sub(queue.at(index-1).Time))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
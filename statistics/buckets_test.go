@@ -0,0 +1,62 @@
+package statistics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatisticBuckets(t *testing.T) {
+	// Test if the checks are counted in their step and latency range
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(3 * time.Minute) }
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(5*time.Minute, 500)
+	s.AddRecord(start.Add(10*time.Second), 100, 200)
+	s.AddRecord(start.Add(40*time.Second), 800, 200)
+	s.AddRecord(start.Add(70*time.Second), 5000, 408)
+	s.AddRecord(start.Add(150*time.Second), 300, 200)
+	buckets := s.Buckets(start, time.Minute, 3, []int{200, 1000, 5000})
+	want := []Bucket{
+		{Checks: 2, Slow: 1, Counts: []int{1, 1, 0}},
+		{Checks: 1, Failures: 1, Counts: []int{0, 0, 1}},
+		{Checks: 1, Counts: []int{0, 1, 0}},
+	}
+	if !reflect.DeepEqual(buckets, want) {
+		t.Errorf("Buckets() == %+v, want %+v", buckets, want)
+	}
+	// Checks outside of the steps are left out
+	if buckets := s.Buckets(start.Add(time.Minute), time.Minute, 1, []int{5000}); buckets[0].Checks != 1 {
+		t.Errorf("Buckets() of the second minute == %+v, want its only check", buckets)
+	}
+}
+
+func TestStatisticCheckInterval(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(time.Hour) }
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(2*time.Hour, 500)
+	if got := s.CheckInterval(); got != 0 {
+		t.Errorf("CheckInterval() without checks == %v, want 0", got)
+	}
+	// Checks every 30s, but for a stall
+	for _, elapsed := range []time.Duration{0, 30, 60, 90, 300, 330} {
+		s.AddRecord(start.Add(elapsed*time.Second), 100, 200)
+	}
+	if got := s.CheckInterval(); got != 30*time.Second {
+		t.Errorf("CheckInterval() == %v, want 30s", got)
+	}
+}
+
+func BenchmarkBuckets(b *testing.B) {
+	s, end := filledStatistic()
+	now = func() time.Time { return end }
+	defer func() { now = time.Now }()
+	bounds := []int{10, 50, 100, 500, 1000, 5000}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Buckets(end.Add(-time.Hour), time.Minute, 60, bounds)
+	}
+}
@@ -0,0 +1,147 @@
+package statistics
+
+import (
+	"encoding/json"
+	"math"
+	"math/bits"
+)
+
+// subBuckets is the number of buckets per power of two. Values are recorded with a relative error below 1/subBuckets.
+const subBuckets = 32
+
+// Histogram is a mergeable sketch of response times. Small values are counted exactly, bigger values are counted
+// in log-linear buckets, so that its memory is bounded whatever the number of records.
+type Histogram struct {
+	counts []uint32
+	total  int
+}
+
+// bucketIndex returns the index of the bucket counting a value
+func bucketIndex(value int) int {
+	if value < 2*subBuckets {
+		if value < 0 {
+			return 0
+		}
+		return value
+	}
+	// Values in [2^(e+5), 2^(e+6)) are counted with a precision of 2^e
+	exponent := bits.Len(uint(value)) - bits.Len(subBuckets)
+	return subBuckets*(exponent+1) + (value >> uint(exponent)) - subBuckets
+}
+
+// bucketValue returns the middle of the range of values counted by a bucket
+func bucketValue(index int) int {
+	if index < 2*subBuckets {
+		return index
+	}
+	exponent := index/subBuckets - 1
+	lowest := (index%subBuckets + subBuckets) << uint(exponent)
+	return lowest + (1<<uint(exponent))/2
+}
+
+// grow extends the buckets of the Histogram to a given number of buckets
+func (h *Histogram) grow(size int) {
+	if size > len(h.counts) {
+		counts := make([]uint32, size)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+}
+
+// Record adds a value to the Histogram
+func (h *Histogram) Record(value int) {
+	index := bucketIndex(value)
+	h.grow(index + 1)
+	h.counts[index]++
+	h.total++
+}
+
+// Remove removes a previously recorded value from the Histogram
+func (h *Histogram) Remove(value int) {
+	index := bucketIndex(value)
+	if index < len(h.counts) && h.counts[index] > 0 {
+		h.counts[index]--
+		h.total--
+	}
+}
+
+// Merge adds the records of another Histogram to the Histogram
+func (h *Histogram) Merge(other *Histogram) {
+	h.grow(len(other.counts))
+	for index, count := range other.counts {
+		h.counts[index] += count
+	}
+	h.total += other.total
+}
+
+// Subtract removes the records of another Histogram, previously merged, from the Histogram
+func (h *Histogram) Subtract(other *Histogram) {
+	for index, count := range other.counts {
+		if index < len(h.counts) && h.counts[index] >= count {
+			h.counts[index] -= count
+			h.total -= int(count)
+		}
+	}
+}
+
+// Count returns the number of values recorded in the Histogram
+func (h *Histogram) Count() int {
+	return h.total
+}
+
+// CountAtMost returns the number of values recorded up to a value, within the precision of the Histogram
+func (h *Histogram) CountAtMost(value int) int {
+	count := 0
+	for index := 0; index <= bucketIndex(value) && index < len(h.counts); index++ {
+		count += int(h.counts[index])
+	}
+	return count
+}
+
+// Percentile returns the value below which p percent of the records fall, or NaN if there are no records
+func (h *Histogram) Percentile(p float64) float64 {
+	if h.total == 0 {
+		return math.NaN()
+	}
+	rank := int(math.Ceil(p / 100.0 * float64(h.total)))
+	if rank < 1 {
+		rank = 1
+	}
+	cumulated := 0
+	for index, count := range h.counts {
+		cumulated += int(count)
+		if cumulated >= rank {
+			return float64(bucketValue(index))
+		}
+	}
+	return float64(bucketValue(len(h.counts) - 1))
+}
+
+// MarshalJSON encodes the non empty buckets of a Histogram
+func (h Histogram) MarshalJSON() ([]byte, error) {
+	buckets := make(map[int]uint32)
+	for index, count := range h.counts {
+		if count > 0 {
+			buckets[index] = count
+		}
+	}
+	return json.Marshal(buckets)
+}
+
+// UnmarshalJSON decodes the buckets of a Histogram
+func (h *Histogram) UnmarshalJSON(data []byte) error {
+	var buckets map[int]uint32
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return err
+	}
+	*h = Histogram{}
+	for index, count := range buckets {
+		if index < 0 {
+			continue
+		}
+		h.grow(index + 1)
+		h.counts[index] += count
+		h.total += int(count)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package statistics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	// Test if percentiles are within the relative error of the sketch
+	var h Histogram
+	for value := 1; value <= 10000; value++ {
+		h.Record(value)
+	}
+	cases := []struct {
+		in   float64
+		want float64
+	}{
+		{50, 5000},
+		{90, 9000},
+		{95, 9500},
+		{99, 9900},
+	}
+	for _, c := range cases {
+		got := h.Percentile(c.in)
+		if math.Abs(got-c.want)/c.want > 1.0/subBuckets {
+			t.Errorf("Percentile(%v) == %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestHistogramMergeAndRemove(t *testing.T) {
+	// Test if merged and removed records are accounted for
+	var h1, h2 Histogram
+	h1.Record(10)
+	h1.Record(20)
+	h2.Record(30)
+	h2.Record(5000)
+	h1.Merge(&h2)
+	if got := h1.Count(); got != 4 {
+		t.Errorf("Count() == %v, want %v", got, 4)
+	}
+	h1.Remove(5000)
+	if got := h1.Percentile(100); got != 30 {
+		t.Errorf("Percentile(100) == %v, want %v", got, 30)
+	}
+	if got := (&Histogram{}).Percentile(50); !math.IsNaN(got) {
+		t.Errorf("Percentile(50) of an empty Histogram == %v, want NaN", got)
+	}
+}
@@ -0,0 +1,157 @@
+package statistics
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// burnWindowBuckets is the number of buckets the shortest burn window of an Objective is divided in, so that the burn rate
+// over it counts at least 3 quarters of its checks
+const burnWindowBuckets = 4
+
+// Burn rates alerting thresholds, for the long window of the fast and slow alerts.
+// With a 30 days window, they fire when 2% of the error budget is burnt within 1 hour, or 5% within 6 hours.
+const (
+	FastBurnRate = 14.4
+	SlowBurnRate = 6.0
+)
+
+// Objective is a service level objective, keeping track of the good and total checks over a time window.
+// A check is good if it succeeded, and its response time is at most the Threshold, if any.
+type Objective struct {
+	mutex  sync.Mutex
+	Name   string
+	Target float64
+	// Response time threshold in ms, 0 for an availability objective
+	Threshold   int
+	window      time.Duration
+	bucketWidth time.Duration
+	buckets     []objectiveBucket
+	head        int
+	good        int
+	total       int
+}
+
+// objectiveBucket counts the good and total checks of a period starting at Time
+type objectiveBucket struct {
+	Time  time.Time
+	Good  int
+	Total int
+}
+
+// NewObjective returns a new Objective, whose target is the fraction of good checks expected over the window
+func NewObjective(name string, target float64, threshold int, window time.Duration) *Objective {
+	o := &Objective{Name: name, Target: target, Threshold: threshold, window: window}
+	// Buckets are sized from the short period of the fast alert, a 8640th of the window, of at least a second
+	_, shortest := o.BurnWindows(true)
+	o.bucketWidth = shortest / burnWindowBuckets
+	if o.bucketWidth < time.Second {
+		o.bucketWidth = time.Second
+	}
+	return o
+}
+
+// Window returns the duration covered by an Objective
+func (o *Objective) Window() time.Duration {
+	return o.window
+}
+
+// AddRecord adds a check to the Objective
+func (o *Objective) AddRecord(t time.Time, responseTime int, statuscode int) {
+	good := 0
+	if statuscode == 200 && (o.Threshold == 0 || responseTime <= o.Threshold) {
+		good = 1
+	}
+	o.AddCount(t, good, 1)
+}
+
+// AddCount adds a number of good checks, out of a total, to the Objective
+func (o *Objective) AddCount(t time.Time, good int, total int) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	start := t.Truncate(o.bucketWidth)
+	// Records are expected to come in order, an older record is counted in the newest bucket
+	if len(o.buckets) == o.head || o.buckets[len(o.buckets)-1].Time.Before(start) {
+		o.buckets = append(o.buckets, objectiveBucket{Time: start})
+	}
+	o.buckets[len(o.buckets)-1].Good += good
+	o.buckets[len(o.buckets)-1].Total += total
+	o.good += good
+	o.total += total
+	o.evict(t)
+}
+
+// evict removes the buckets older than the Objective window, relatively to a reference time
+func (o *Objective) evict(reference time.Time) {
+	limit := reference.Add(-o.window)
+	for o.head < len(o.buckets) && o.buckets[o.head].Time.Before(limit) {
+		o.good -= o.buckets[o.head].Good
+		o.total -= o.buckets[o.head].Total
+		o.head++
+	}
+	// Reclaiming the space of the evicted buckets once they make up half of the slice
+	if o.head >= 64 && o.head*2 >= len(o.buckets) {
+		o.buckets = append(o.buckets[:0], o.buckets[o.head:]...)
+		o.head = 0
+	}
+}
+
+// Compliance returns the fraction of good checks over the window, or NaN without checks
+func (o *Objective) Compliance() float64 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.evict(now())
+	return float64(o.good) / float64(o.total)
+}
+
+// ErrorBudgetRemaining returns the fraction of the error budget left over the window. It is negative once the budget is exhausted.
+func (o *Objective) ErrorBudgetRemaining() float64 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.evict(now())
+	if o.total == 0 {
+		return 1
+	}
+	return 1 - float64(o.total-o.good)/float64(o.total)/(1-o.Target)
+}
+
+// BurnRate returns how fast the error budget is consumed over the last period of time, 1 meaning it would be exactly
+// exhausted at the end of the window. It is NaN without checks.
+func (o *Objective) BurnRate(period time.Duration) float64 {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.evict(now())
+	limit := now().Add(-period)
+	good, total := 0, 0
+	for index := len(o.buckets) - 1; index >= o.head && !o.buckets[index].Time.Before(limit); index-- {
+		good += o.buckets[index].Good
+		total += o.buckets[index].Total
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+	return float64(total-good) / float64(total) / (1 - o.Target)
+}
+
+// BurnWindows returns the long and short periods over which the burn rate of the fast (or slow) alert is computed.
+// For a 30 days window, they are 1 hour and 5 minutes for the fast alert, 6 hours and 30 minutes for the slow one.
+func (o *Objective) BurnWindows(fast bool) (time.Duration, time.Duration) {
+	long := o.window / 120
+	if fast {
+		long = o.window / 720
+	}
+	return long, long / 12
+}
+
+// Burning returns whether the error budget burns faster than the fast (or slow) alert threshold over both of its
+// periods, along with the burn rate of the long period.
+func (o *Objective) Burning(fast bool) (bool, float64) {
+	threshold := SlowBurnRate
+	if fast {
+		threshold = FastBurnRate
+	}
+	long, short := o.BurnWindows(fast)
+	longRate := o.BurnRate(long)
+	return longRate > threshold && o.BurnRate(short) > threshold, longRate
+}
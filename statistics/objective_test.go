@@ -0,0 +1,75 @@
+package statistics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestObjectiveErrorBudget(t *testing.T) {
+	// Test the error budget and burn rates of a 99% objective over 30 days
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+
+	o := NewObjective("availability", 0.99, 0, 30*24*time.Hour)
+	// A week of good checks every minute, then an hour of failures
+	week := 7 * 24 * 60
+	for i := 0; i < week; i++ {
+		o.AddRecord(start.Add(time.Duration(i)*time.Minute), 100, 200)
+	}
+	for i := week; i < week+60; i++ {
+		o.AddRecord(start.Add(time.Duration(i)*time.Minute), 100, 500)
+	}
+	now = func() time.Time { return start.Add(time.Duration(week+60) * time.Minute) }
+
+	// 60 bad checks out of 10140, for a budget of 1%
+	wantRemaining := 1 - 60.0/10140.0/0.01
+	if got := o.ErrorBudgetRemaining(); math.Abs(got-wantRemaining) > 1e-9 {
+		t.Errorf("ErrorBudgetRemaining() == %v, want %v", got, wantRemaining)
+	}
+	if burning, rate := o.Burning(true); !burning || math.Abs(rate-100) > 1e-6 {
+		t.Errorf("Burning(true) == %v, %v, want true, 100", burning, rate)
+	}
+	// Over 6 hours, 60 bad checks out of 360
+	if burning, _ := o.Burning(false); !burning {
+		t.Errorf("Burning(false) == false, want true")
+	}
+	// A latency objective only counts fast checks as good
+	latency := NewObjective("latency", 0.95, 500, time.Hour)
+	latency.AddRecord(now(), 600, 200)
+	if got := latency.Compliance(); got != 0 {
+		t.Errorf("Compliance() == %v, want %v", got, 0)
+	}
+}
+
+func TestObjectiveShortWindow(t *testing.T) {
+	// Test the fast burn alert of a 99% objective over a day, whose periods are 2 minutes and 10 seconds, and its recovery
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+
+	o := NewObjective("availability", 0.99, 0, 24*time.Hour)
+	if long, short := o.BurnWindows(true); long != 2*time.Minute || short != 10*time.Second {
+		t.Errorf("BurnWindows(true) == %v, %v, want 2m0s, 10s", long, short)
+	}
+	// An hour of good checks every 5 seconds, then 2 minutes and a half of failures
+	at := start
+	for ; at.Before(start.Add(time.Hour)); at = at.Add(5 * time.Second) {
+		o.AddRecord(at, 100, 200)
+	}
+	for ; at.Before(start.Add(time.Hour + 150*time.Second)); at = at.Add(5 * time.Second) {
+		o.AddRecord(at, 100, 500)
+	}
+	now = func() time.Time { return at.Add(-time.Second) }
+	if burning, rate := o.Burning(true); !burning || math.Abs(rate-100) > 1e-6 {
+		t.Errorf("Burning(true) == %v, %v, want true, 100", burning, rate)
+	}
+
+	// The alert stops once the short period is good again, although the long one still burns
+	for end := at.Add(20 * time.Second); at.Before(end); at = at.Add(5 * time.Second) {
+		o.AddRecord(at, 100, 200)
+	}
+	now = func() time.Time { return at.Add(-time.Second) }
+	if burning, rate := o.Burning(true); burning || rate <= FastBurnRate {
+		t.Errorf("Burning(true) == %v, %v, want false with a long burn rate above %v", burning, rate, FastBurnRate)
+	}
+}
@@ -1,107 +1,351 @@
 package statistics
 
-// Statistic is a structure containing information on the last response times and status codes retrieved
+import (
+	"sync"
+	"time"
+)
+
+// now returns the current time. It is a variable so that tests can control the clock.
+var now = time.Now
+
+// Statistic is a structure containing information on the response times and status codes retrieved within a time window
 type Statistic struct {
-	recentStats       evictingQueue
+	mutex  sync.Mutex
+	window time.Duration
+	// Checks of the last raw window, and aggregates of the older checks over steps, if the window is longer
+	recentStats      timeQueue
+	step             time.Duration
+	aggregates       []aggregate
+	sequence         uint64
+	maxResponseTimes extremumQueue
+	minResponseTimes extremumQueue
+	// Totals of every check of the window
+	checks            int
 	totalResponseTime int
-	StatusCodeCount   map[int]int
+	statusCodeCount   map[int]int
+	responseTimes     Histogram
+	// Apdex target response time in ms, and number of satisfied and tolerating records
+	apdexTarget int
+	satisfied   int
+	tolerating  int
 }
 
-// evictingQueue is a queue with a fixed size. When full, enqueueing an element will dequeue the oldest element
-type evictingQueue struct {
-	items    []item
-	size     int
-	selector int
-	filled   bool
+// timeQueue is a queue of timestamped items, ordered from the oldest to the most recent.
+// Concurrent checks may complete in another order than they started : their items are inserted in the order of their times.
+type timeQueue struct {
+	items []item
+	head  int
 }
 
-// item is an element of the evictingQueue. It contains a response time and a status code.
+// item is an element of the timeQueue. It contains the time of a check, its response time and its status code.
 type item struct {
+	Time         time.Time
 	ResponseTime int
 	Statuscode   int
+	// Reason of a failed check, empty if the check succeeded
+	reason string
+	// sequence identifies the item within its Statistic
+	sequence uint64
+}
+
+// extremumQueue is a monotonic queue of items, whose oldest item has the biggest (or smallest) response time of a window.
+// Items which can no longer be the extremum of the window are dropped as soon as they are outdone by a newer item,
+// so that both updates and queries are done in amortized constant time.
+type extremumQueue struct {
+	timeQueue
+	// Whether the queue keeps track of the smallest response time rather than the biggest
+	minimum bool
 }
 
-// AddRecord adds a record of response time and status code to the Statistic Structure
-func (s *Statistic) AddRecord(responseTime int, statuscode int) {
-	// Enqueue the new item
-	oldestItem := s.recentStats.enqueue(item{ResponseTime: responseTime, Statuscode: statuscode})
-	// Update totalResponseTime and StatusCodeCount
-	s.totalResponseTime += responseTime - oldestItem.ResponseTime
-	if oldestItem.Statuscode > 0 {
-		s.StatusCodeCount[oldestItem.Statuscode]--
+// NewStatistic returns a new Statistic keeping track of the records of the last window of time.
+// The apdex target is the response time in ms under which a successful check is satisfying.
+// Checks older than an hour are aggregated over steps splitting the window into at most 250 steps, evicted once the whole step is older than the window.
+func NewStatistic(window time.Duration, apdexTarget int) *Statistic {
+	return &Statistic{
+		window:           window,
+		step:             aggregateStep(window),
+		apdexTarget:      apdexTarget,
+		minResponseTimes: extremumQueue{minimum: true},
+		statusCodeCount:  make(map[int]int),
 	}
-	s.StatusCodeCount[statuscode]++
 }
 
-// NewStatistic returns a new Statistic
-func NewStatistic(size int) *Statistic {
-	return &Statistic{*newEvictingQueue(size), 0, make(map[int]int)}
+// Window returns the duration covered by a Statistic
+func (s *Statistic) Window() time.Duration {
+	return s.window
 }
 
-// newEvictingQueue returns a initialized EvictingQueue
-func newEvictingQueue(size int) *evictingQueue {
-	return &evictingQueue{make([]item, size), size, 0, false}
+// ApdexTarget returns the response time in ms under which a successful check is satisfying
+func (s *Statistic) ApdexTarget() int {
+	return s.apdexTarget
 }
 
-// enqueue enqueue an element in the queue, and return the oldest element evicted
-func (q *evictingQueue) enqueue(i item) item {
-	// Update filled status
-	if !q.filled && q.selector == q.size-1 {
-		q.filled = true
-	}
-	previousItem := q.items[q.selector]
-	q.items[q.selector] = i
-	// Increment selector
-	q.selector = (q.selector + 1) % q.size
-	return previousItem
+// Sample is a check kept by a Statistic : its time, response time, status code and failure reason
+type Sample struct {
+	Time         time.Time
+	ResponseTime int
+	StatusCode   int
+	// Reason of a failed check, empty if the check succeeded
+	Reason string
+}
+
+// AddRecord adds a record of response time and status code, checked at a given time, to the Statistic Structure
+func (s *Statistic) AddRecord(t time.Time, responseTime int, statuscode int) {
+	s.AddSample(Sample{Time: t, ResponseTime: responseTime, StatusCode: statuscode})
 }
 
-// length returns the length of an evictingQueue (size if the queue is filled)
-func (q *evictingQueue) length() int {
-	if q.filled {
-		return q.size
+// AddSample adds a sample to the Statistic Structure, keeping its failure reason
+func (s *Statistic) AddSample(sample Sample) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// Enqueue the new item, in the order of the checks
+	s.sequence++
+	newItem := item{Time: sample.Time, ResponseTime: sample.ResponseTime, Statuscode: sample.StatusCode, reason: sample.Reason, sequence: s.sequence}
+	s.recentStats.push(newItem)
+	s.maxResponseTimes.push(newItem)
+	s.minResponseTimes.push(newItem)
+	s.count(newItem, 1)
+	s.evict(sample.Time)
+}
+
+// count adds (or removes) an item to the totals of the window
+func (s *Statistic) count(i item, delta int) {
+	s.checks += delta
+	s.totalResponseTime += delta * i.ResponseTime
+	s.statusCodeCount[i.Statuscode] += delta
+	if delta > 0 {
+		s.responseTimes.Record(i.ResponseTime)
+	} else {
+		s.responseTimes.Remove(i.ResponseTime)
 	}
-	return q.selector
+	s.countApdex(i, delta)
 }
 
-// maxResponseTime returns the biggest response time of the queue's elements
-func (q *evictingQueue) maxResponseTime() int {
-	max := 0
-	for _, item := range q.items {
-		if item.ResponseTime > max {
-			max = item.ResponseTime
+// evict removes the records older than the Statistic window, relatively to a reference time.
+// The checks of a long window older than the raw window are aggregated.
+func (s *Statistic) evict(reference time.Time) {
+	limit := reference.Add(-s.window)
+	rawLimit := limit
+	if s.step > 0 {
+		rawLimit = reference.Add(-rawWindow)
+	}
+	for s.recentStats.length() > 0 && !s.recentStats.oldest().Time.After(rawLimit) {
+		oldestItem := s.recentStats.pop()
+		s.maxResponseTimes.evict(oldestItem)
+		s.minResponseTimes.evict(oldestItem)
+		if oldestItem.Time.After(limit) {
+			s.aggregate(oldestItem)
+		} else {
+			s.count(oldestItem, -1)
 		}
 	}
-	return max
+	for len(s.aggregates) > 0 && !s.aggregates[0].last.After(limit) {
+		s.evictAggregate()
+	}
+}
+
+// countApdex adds (or removes) an item to the satisfied or tolerating records. Failed checks are frustrating.
+func (s *Statistic) countApdex(i item, delta int) {
+	if i.Statuscode != 200 {
+		return
+	}
+	if i.ResponseTime <= s.apdexTarget {
+		s.satisfied += delta
+	} else if i.ResponseTime <= 4*s.apdexTarget {
+		s.tolerating += delta
+	}
+}
+
+// push enqueue an element after the elements of the queue which are not more recent
+func (q *timeQueue) push(i item) {
+	index := q.after(i.Time)
+	q.replace(index, index, i)
+}
+
+// after returns the index of the oldest element more recent than a time, the length of the queue if there is none.
+// Elements are usually pushed in order, the queue is searched from its most recent element.
+func (q *timeQueue) after(t time.Time) int {
+	index := q.length()
+	for index > 0 && q.at(index-1).Time.After(t) {
+		index--
+	}
+	return index
+}
+
+// replace replaces the elements of the queue from one index to another, excluded, by an element
+func (q *timeQueue) replace(from int, to int, i item) {
+	if from == to {
+		q.items = append(q.items, item{})
+		copy(q.items[q.head+from+1:], q.items[q.head+from:])
+	} else {
+		q.items = append(q.items[:q.head+from+1], q.items[q.head+to:]...)
+	}
+	q.items[q.head+from] = i
+}
+
+// oldest returns the oldest element of a non empty queue
+func (q *timeQueue) oldest() item {
+	return q.items[q.head]
+}
+
+// pop dequeues the oldest element of a non empty queue
+func (q *timeQueue) pop() item {
+	oldestItem := q.items[q.head]
+	q.head++
+	// Reclaiming the space of the dequeued elements once they make up half of the queue
+	if q.head >= 64 && q.head*2 >= len(q.items) {
+		q.items = append(q.items[:0], q.items[q.head:]...)
+		q.head = 0
+	}
+	return oldestItem
+}
+
+// length returns the number of elements in a timeQueue
+func (q *timeQueue) length() int {
+	return len(q.items) - q.head
+}
+
+// at returns the element of the queue at an index, starting from the oldest
+func (q *timeQueue) at(index int) item {
+	return q.items[q.head+index]
+}
+
+// push enqueue an element in time order, dropping the older elements it outdoes, unless a more recent element outdoes it
+func (q *extremumQueue) push(i item) {
+	index := q.after(i.Time)
+	if index < q.length() && q.outdoes(q.at(index), i) {
+		return
+	}
+	first := index
+	for first > 0 && q.outdoes(i, q.at(first-1)) {
+		first--
+	}
+	q.replace(first, index, i)
+}
+
+// outdoes returns whether an item's response time is at least as extreme as another one's
+func (q *extremumQueue) outdoes(i item, other item) bool {
+	if q.minimum {
+		return i.ResponseTime <= other.ResponseTime
+	}
+	return i.ResponseTime >= other.ResponseTime
+}
+
+// evict dequeues an item evicted from the window, if it was still part of the queue
+func (q *extremumQueue) evict(i item) {
+	if q.length() > 0 && q.oldest().sequence == i.sequence {
+		q.pop()
+	}
+}
+
+// extremum returns the extremum response time of the window, or 0 if the queue is empty
+func (q *extremumQueue) extremum() int {
+	if q.length() == 0 {
+		return 0
+	}
+	return q.oldest().ResponseTime
 }
 
 // MaxResponseTime returns the biggest response Time of a Statistic
 func (s *Statistic) MaxResponseTime() int {
-	return s.recentStats.maxResponseTime()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	max := s.maxResponseTimes.extremum()
+	for _, a := range s.aggregates {
+		if a.maxResponseTime > max {
+			max = a.maxResponseTime
+		}
+	}
+	return max
+}
+
+// MinResponseTime returns the smallest response Time of a Statistic
+func (s *Statistic) MinResponseTime() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	min, found := s.minResponseTimes.extremum(), s.minResponseTimes.length() > 0
+	for _, a := range s.aggregates {
+		if !found || a.minResponseTime < min {
+			min, found = a.minResponseTime, true
+		}
+	}
+	return min
 }
 
 // Average returns the responseTime average of a Statistic
 func (s *Statistic) Average() float64 {
-	return float64(s.totalResponseTime) / float64(s.recentStats.length())
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	return float64(s.totalResponseTime) / float64(s.checks)
+}
+
+// Percentile returns the response time below which p percent of the response times of a Statistic fall
+func (s *Statistic) Percentile(p float64) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	return s.responseTimes.Percentile(p)
 }
 
 // Availability returns the availability of a Statistic. A 1.0 availability means there are only 200 Status code responses.
 func (s *Statistic) Availability() float64 {
-	return float64(s.StatusCodeCount[200]) / float64(s.recentStats.length())
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	return float64(s.statusCodeCount[200]) / float64(s.checks)
 }
 
-// length returns the length of an EvictingQueue (size if the queue is filled)
-func (s *Statistic) length() int {
-	return s.recentStats.length()
+// Apdex returns the Apdex score of a Statistic, from 0 (every user is frustrated) to 1 (every user is satisfied)
+func (s *Statistic) Apdex() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	return (float64(s.satisfied) + float64(s.tolerating)/2) / float64(s.checks)
 }
 
-// RecentResponseTime returns a list of the response time recorded as float64, starting from the most recent to the oldest.
-func (s *Statistic) RecentResponseTime() []float64 {
+// StatusCodeCount returns the number of records of each status code
+func (s *Statistic) StatusCodeCount() map[int]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	statusCodeCount := make(map[int]int, len(s.statusCodeCount))
+	for statusCode, count := range s.statusCodeCount {
+		statusCodeCount[statusCode] = count
+	}
+	return statusCodeCount
+}
+
+// RecentResponseTime returns a list of the most recent response times recorded as float64, up to a limit, starting from the most recent to the oldest.
+// Only those are read, so that long windows stay cheap to chart.
+func (s *Statistic) RecentResponseTime(limit int) []float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
 	queue := s.recentStats
-	responseTimes := make([]float64, queue.length())
-	selector := queue.selector
-	for index := 0; index < queue.length(); index++ {
-		responseTimes[index] = float64(queue.items[(queue.size+selector-index-1)%queue.size].ResponseTime)
+	if limit > queue.length() {
+		limit = queue.length()
+	}
+	responseTimes := make([]float64, limit)
+	for index := 0; index < limit; index++ {
+		responseTimes[index] = float64(queue.at(queue.length() - index - 1).ResponseTime)
 	}
 	return responseTimes
 }
+
+// Samples returns the samples within the window, starting from the oldest to the most recent.
+// Only the samples of the last hour are kept by the windows longer than an hour.
+func (s *Statistic) Samples() []Sample {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict(now())
+	queue := s.recentStats
+	samples := make([]Sample, queue.length())
+	for index := range samples {
+		item := queue.at(index)
+		samples[index] = Sample{Time: item.Time, ResponseTime: item.ResponseTime, StatusCode: item.Statuscode, Reason: item.reason}
+	}
+	return samples
+}
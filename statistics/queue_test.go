@@ -0,0 +1,218 @@
+package statistics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatisticTimeWindow(t *testing.T) {
+	// Test if records are evicted by age rather than by count
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(125 * time.Second) }
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(2*time.Minute, 500)
+	s.AddRecord(start, 100, 500)
+	s.AddRecord(start.Add(30*time.Second), 300, 200)
+	// A stall: no check for more than a minute
+	s.AddRecord(start.Add(100*time.Second), 200, 200)
+
+	if got := s.Average(); got != 250 {
+		t.Errorf("Average() == %v, want %v", got, 250)
+	}
+	if got := s.MaxResponseTime(); got != 300 {
+		t.Errorf("MaxResponseTime() == %v, want %v", got, 300)
+	}
+	if got := s.Availability(); got != 1 {
+		t.Errorf("Availability() == %v, want %v", got, 1)
+	}
+	if got := s.Apdex(); got != 1 {
+		t.Errorf("Apdex() == %v, want %v", got, 1)
+	}
+	// Every record is evicted once the window has elapsed
+	now = func() time.Time { return start.Add(time.Hour) }
+	if got := s.Availability(); !math.IsNaN(got) {
+		t.Errorf("Availability() == %v, want NaN", got)
+	}
+}
+
+func TestStatisticExtremums(t *testing.T) {
+	// Test if max and min response times follow the evictions
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(time.Minute, 500)
+	responseTimes := []int{50, 400, 30, 200, 100, 300}
+	cases := []struct {
+		elapsed time.Duration
+		wantMax int
+		wantMin int
+	}{
+		{50 * time.Second, 400, 30},
+		{70 * time.Second, 300, 30},
+		{90 * time.Second, 300, 100},
+		{105 * time.Second, 300, 300},
+		{time.Hour, 0, 0},
+	}
+	for i, responseTime := range responseTimes {
+		s.AddRecord(start.Add(time.Duration(i)*10*time.Second), responseTime, 200)
+	}
+	for _, c := range cases {
+		now = func() time.Time { return start.Add(c.elapsed) }
+		if got := s.MaxResponseTime(); got != c.wantMax {
+			t.Errorf("MaxResponseTime() after %v == %v, want %v", c.elapsed, got, c.wantMax)
+		}
+		if got := s.MinResponseTime(); got != c.wantMin {
+			t.Errorf("MinResponseTime() after %v == %v, want %v", c.elapsed, got, c.wantMin)
+		}
+	}
+}
+
+func TestStatisticOutOfOrder(t *testing.T) {
+	// Test if a check completing after a more recent one is queued in the order of the checks
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(time.Minute, 500)
+	responseTimes := []int{50, 400, 30, 200, 100, 300}
+	// The slow checks complete after the next ones
+	for _, i := range []int{0, 2, 1, 4, 3, 5} {
+		s.AddRecord(start.Add(time.Duration(i)*10*time.Second), responseTimes[i], 200)
+	}
+	for index := 1; index < s.recentStats.length(); index++ {
+		if s.recentStats.at(index).Time.Before(s.recentStats.at(index - 1).Time) {
+			t.Errorf("item %d at %v is queued after item %d at %v", index-1, s.recentStats.at(index-1).Time, index, s.recentStats.at(index).Time)
+		}
+	}
+	// The extremums follow the evictions as if the checks completed in order
+	cases := []struct {
+		elapsed time.Duration
+		wantMax int
+		wantMin int
+	}{
+		{50 * time.Second, 400, 30},
+		{70 * time.Second, 300, 30},
+		{90 * time.Second, 300, 100},
+		{105 * time.Second, 300, 300},
+	}
+	for _, c := range cases {
+		now = func() time.Time { return start.Add(c.elapsed) }
+		if got := s.MaxResponseTime(); got != c.wantMax {
+			t.Errorf("MaxResponseTime() after %v == %v, want %v", c.elapsed, got, c.wantMax)
+		}
+		if got := s.MinResponseTime(); got != c.wantMin {
+			t.Errorf("MinResponseTime() after %v == %v, want %v", c.elapsed, got, c.wantMin)
+		}
+	}
+	// The steps are searched among the checks in their order
+	now = func() time.Time { return start.Add(60 * time.Second) }
+	late := NewStatistic(time.Minute, 500)
+	late.AddRecord(start.Add(25*time.Second), 100, 200)
+	late.AddRecord(start.Add(10*time.Second), 100, 200)
+	if buckets := late.Buckets(start.Add(20*time.Second), 10*time.Second, 1, nil); buckets[0].Checks != 1 {
+		t.Errorf("Buckets() == %+v, want the check completed first", buckets)
+	}
+}
+
+func TestStatisticRecentResponseTime(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(time.Minute) }
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(time.Hour, 500)
+	for i, responseTime := range []int{100, 200, 300} {
+		s.AddRecord(start.Add(time.Duration(i)*time.Second), responseTime, 200)
+	}
+	if got := s.RecentResponseTime(2); !reflect.DeepEqual(got, []float64{300, 200}) {
+		t.Errorf("RecentResponseTime(2) == %v, want the 2 most recent", got)
+	}
+	if got := s.RecentResponseTime(10); !reflect.DeepEqual(got, []float64{300, 200, 100}) {
+		t.Errorf("RecentResponseTime(10) == %v, want every response time", got)
+	}
+}
+
+// filledStatistic returns a 1 hour Statistic filled with a record per second
+func filledStatistic() (*Statistic, time.Time) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewStatistic(time.Hour, 500)
+	for i := 0; i < 3600; i++ {
+		s.AddRecord(start.Add(time.Duration(i)*time.Second), (i*7919)%5000, 200)
+	}
+	return s, start.Add(3600 * time.Second)
+}
+
+func BenchmarkAddRecord(b *testing.B) {
+	s, end := filledStatistic()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Each record evicts the oldest one
+		s.AddRecord(end.Add(time.Duration(i)*time.Second), (i*7919)%5000, 200)
+	}
+}
+
+func BenchmarkMaxResponseTime(b *testing.B) {
+	s, end := filledStatistic()
+	now = func() time.Time { return end }
+	defer func() { now = time.Now }()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.MaxResponseTime()
+	}
+}
+
+func BenchmarkMaxResponseTimeScan(b *testing.B) {
+	// Baseline : scanning every record of the window, as done before the extremum queues
+	s, _ := filledStatistic()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		max := 0
+		for index := 0; index < s.recentStats.length(); index++ {
+			if s.recentStats.at(index).ResponseTime > max {
+				max = s.recentStats.at(index).ResponseTime
+			}
+		}
+	}
+}
+
+func TestStatisticApdex(t *testing.T) {
+	// Test if records are satisfying, tolerating or frustrating
+	start := time.Now()
+	s := NewStatistic(time.Hour, 100)
+	s.AddRecord(start, 50, 200)
+	s.AddRecord(start, 100, 200)
+	s.AddRecord(start, 300, 200)
+	s.AddRecord(start, 1000, 200)
+	s.AddRecord(start, 50, 500)
+	// (2 satisfied + 1 tolerating / 2) / 5
+	if got := s.Apdex(); got != 0.5 {
+		t.Errorf("Apdex() == %v, want %v", got, 0.5)
+	}
+}
+
+func TestStatisticSamples(t *testing.T) {
+	// Test if the samples of the window are returned with their time, status code and failure reason
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(150 * time.Second) }
+	defer func() { now = time.Now }()
+
+	s := NewStatistic(2*time.Minute, 500)
+	s.AddRecord(start, 100, 200)
+	s.AddSample(Sample{Time: start.Add(60 * time.Second), ResponseTime: 5000, StatusCode: 408, Reason: "timeout"})
+	s.AddRecord(start.Add(120*time.Second), 200, 200)
+
+	want := []Sample{
+		{Time: start.Add(60 * time.Second), ResponseTime: 5000, StatusCode: 408, Reason: "timeout"},
+		{Time: start.Add(120 * time.Second), ResponseTime: 200, StatusCode: 200},
+	}
+	samples := s.Samples()
+	if len(samples) != len(want) {
+		t.Fatalf("Samples() == %v, want %v", samples, want)
+	}
+	for i := range want {
+		if samples[i] != want[i] {
+			t.Errorf("Samples()[%d] == %v, want %v", i, samples[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package statistics
+
+import "math"
+
+// Snapshot is the state of a Statistic at a given time, in ms. Values are nil without records.
+type Snapshot struct {
+	Checks       int         `json:"checks"`
+	Average      *float64    `json:"averageMs"`
+	Min          *float64    `json:"minMs"`
+	Max          *float64    `json:"maxMs"`
+	P50          *float64    `json:"p50Ms"`
+	P90          *float64    `json:"p90Ms"`
+	P95          *float64    `json:"p95Ms"`
+	P99          *float64    `json:"p99Ms"`
+	Availability *float64    `json:"availability"`
+	Apdex        *float64    `json:"apdex"`
+	StatusCodes  map[int]int `json:"statusCodes"`
+}
+
+// ObjectiveSnapshot is the state of an Objective at a given time. Values are nil without checks.
+type ObjectiveSnapshot struct {
+	Name            string   `json:"name"`
+	Target          float64  `json:"target"`
+	Compliance      *float64 `json:"compliance"`
+	BudgetRemaining float64  `json:"budgetRemaining"`
+	FastBurnRate    *float64 `json:"fastBurnRate"`
+	SlowBurnRate    *float64 `json:"slowBurnRate"`
+}
+
+// Snapshot returns the current state of a Statistic
+func (s *Statistic) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Average:      nullable(s.Average()),
+		P50:          nullable(s.Percentile(50)),
+		P90:          nullable(s.Percentile(90)),
+		P95:          nullable(s.Percentile(95)),
+		P99:          nullable(s.Percentile(99)),
+		Availability: nullable(s.Availability()),
+		Apdex:        nullable(s.Apdex()),
+		StatusCodes:  s.StatusCodeCount(),
+	}
+	for _, count := range snapshot.StatusCodes {
+		snapshot.Checks += count
+	}
+	if snapshot.Checks > 0 {
+		snapshot.Min = nullable(float64(s.MinResponseTime()))
+		snapshot.Max = nullable(float64(s.MaxResponseTime()))
+	}
+	return snapshot
+}
+
+// Snapshot returns the current state of an Objective
+func (o *Objective) Snapshot() ObjectiveSnapshot {
+	fastLong, _ := o.BurnWindows(true)
+	slowLong, _ := o.BurnWindows(false)
+	return ObjectiveSnapshot{
+		Name:            o.Name,
+		Target:          o.Target,
+		Compliance:      nullable(o.Compliance()),
+		BudgetRemaining: o.ErrorBudgetRemaining(),
+		FastBurnRate:    nullable(o.BurnRate(fastLong)),
+		SlowBurnRate:    nullable(o.BurnRate(slowLong)),
+	}
+}
+
+// nullable returns a pointer to a value, or nil if it is NaN
+func nullable(value float64) *float64 {
+	if math.IsNaN(value) {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,229 @@
+package statuspage
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+)
+
+// staleAfter is how long after its last check the state of a website is unknown
+const staleAfter = time.Hour
+
+// recentIncidents is how long resolved incidents are listed on the page
+const recentIncidents = 14 * 24 * time.Hour
+
+// States of a website
+const (
+	Operational = "operational"
+	Down        = "down"
+	Unknown     = "unknown"
+)
+
+// Config is the configuration of a status page, read from the statusPage section of the JSON configuration
+type Config struct {
+	Title string `json:"title"`
+	// Public URL of the status page, linked from the feeds
+	URL    string        `json:"url"`
+	Groups []GroupConfig `json:"groups"`
+}
+
+// GroupConfig is a group of websites of a status page
+type GroupConfig struct {
+	Name     string          `json:"name"`
+	Websites []WebsiteConfig `json:"websites"`
+}
+
+// WebsiteConfig is a website of a status page, shown with its friendly name rather than its URL
+type WebsiteConfig struct {
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// Page is the content of a status page
+type Page struct {
+	Title     string
+	URL       string
+	Generated time.Time
+	// Number of days of the uptime bars
+	Days   int
+	Groups []Group
+	// Ongoing incidents, and recently resolved ones, from the most recent
+	Incidents []Incident
+	// Every incident of the period, from the most recent, for the feeds
+	Feed []Incident
+}
+
+// Group is a group of websites of a status page
+type Group struct {
+	Name     string
+	Websites []Website
+}
+
+// Website is the state and uptime of a website
+type Website struct {
+	Name string
+	// Operational, Down or Unknown
+	State string
+	// Fraction of successful checks over the period, NaN without checks
+	Uptime float64
+	// Uptime of each day, from the oldest
+	Days []Day
+}
+
+// Day is the uptime of a website over a day
+type Day struct {
+	Date   time.Time
+	Checks int
+	// Fraction of successful checks, NaN without checks
+	Uptime float64
+}
+
+// Incident is an incident of a website, described without its failure reason which may not be public
+type Incident struct {
+	Website string
+	Start   time.Time
+	// End of the incident, zero while it is ongoing
+	End time.Time
+}
+
+// Ongoing returns whether an Incident is still open
+func (i Incident) Ongoing() bool {
+	return i.End.IsZero()
+}
+
+// Duration returns the duration of an Incident, up to now if it is ongoing
+func (i Incident) Duration(now time.Time) time.Duration {
+	if i.Ongoing() {
+		return now.Sub(i.Start)
+	}
+	return i.End.Sub(i.Start)
+}
+
+// State returns the overall state of a Page : Operational if every website is, Down if any is, Unknown otherwise
+func (p Page) State() string {
+	state := Operational
+	for _, group := range p.Groups {
+		for _, website := range group.Websites {
+			if website.State == Down {
+				return Down
+			}
+			if website.State == Unknown {
+				state = Unknown
+			}
+		}
+	}
+	return state
+}
+
+// Summarize computes the state and the daily uptimes of a website over the days ending with the day of now, in the location of now.
+// A rollup counts its checks, and the last check gives the state unless an incident is ongoing.
+func Summarize(name string, records []history.Record, rollups []history.Rollup, urlIncidents []incidents.Incident, days int, now time.Time) Website {
+	website := Website{Name: name, State: Unknown, Uptime: math.NaN(), Days: make([]Day, days)}
+	first := startOfDay(now).AddDate(0, 0, 1-days)
+	for index := range website.Days {
+		website.Days[index].Date = first.AddDate(0, 0, index)
+	}
+	successes := make([]int, days)
+	add := func(t time.Time, checks int, succeeded int) {
+		day := dayIndex(first, t)
+		if day < 0 || day >= days {
+			return
+		}
+		website.Days[day].Checks += checks
+		successes[day] += succeeded
+	}
+	for _, rollup := range rollups {
+		add(rollup.Time, rollup.Count, rollup.StatusCodeCount[200])
+	}
+	var last history.Record
+	for _, record := range records {
+		succeeded := 0
+		if record.StatusCode == 200 {
+			succeeded = 1
+		}
+		add(record.Time, 1, succeeded)
+		if record.Time.After(last.Time) {
+			last = record
+		}
+	}
+	var checks, succeeded int
+	for index := range website.Days {
+		website.Days[index].Uptime = math.NaN()
+		if website.Days[index].Checks > 0 {
+			website.Days[index].Uptime = float64(successes[index]) / float64(website.Days[index].Checks)
+		}
+		checks += website.Days[index].Checks
+		succeeded += successes[index]
+	}
+	if checks > 0 {
+		website.Uptime = float64(succeeded) / float64(checks)
+	}
+	// The state is unknown once the website is no longer checked
+	if !last.Time.IsZero() && now.Sub(last.Time) < staleAfter {
+		website.State = Operational
+		if last.StatusCode != 200 || (len(urlIncidents) > 0 && urlIncidents[len(urlIncidents)-1].Ongoing()) {
+			website.State = Down
+		}
+	}
+	return website
+}
+
+// Build computes a status page from a history over the last days. Websites are read from the history by their key, their URL as written in the configuration.
+func Build(store *history.Store, config Config, days int, now time.Time) (Page, error) {
+	page := Page{Title: config.Title, URL: config.URL, Generated: now, Days: days}
+	from := startOfDay(now).AddDate(0, 0, 1-days)
+	for _, groupConfig := range config.Groups {
+		group := Group{Name: groupConfig.Name}
+		for _, websiteConfig := range groupConfig.Websites {
+			records, err := store.Records(websiteConfig.URL, from, now)
+			if err != nil {
+				return page, err
+			}
+			rollups, err := store.Rollups(websiteConfig.URL, from, now)
+			if err != nil {
+				return page, err
+			}
+			urlIncidents, err := store.Incidents(websiteConfig.URL, from, now)
+			if err != nil {
+				return page, err
+			}
+			website := Summarize(websiteConfig.Name, records, rollups, urlIncidents, days, now)
+			group.Websites = append(group.Websites, website)
+			for _, incident := range urlIncidents {
+				// An ongoing incident of a website no longer checked ended at an unknown time
+				if incident.Ongoing() && website.State == Unknown {
+					continue
+				}
+				page.Feed = append(page.Feed, Incident{Website: websiteConfig.Name, Start: incident.Start, End: incident.End})
+			}
+		}
+		page.Groups = append(page.Groups, group)
+	}
+	sort.SliceStable(page.Feed, func(i, j int) bool {
+		return page.Feed[i].Start.After(page.Feed[j].Start)
+	})
+	for _, incident := range page.Feed {
+		if incident.Ongoing() || now.Sub(incident.End) < recentIncidents {
+			page.Incidents = append(page.Incidents, incident)
+		}
+	}
+	return page, nil
+}
+
+// startOfDay returns the midnight starting the day of a time, in its location
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// dayIndex returns the index of the day of a time, counted from the midnight first. Days with a daylight saving change are handled.
+func dayIndex(first time.Time, t time.Time) int {
+	t = t.In(first.Location())
+	if t.Before(first) {
+		return -1
+	}
+	return int(startOfDay(t).Sub(first).Hours()/24 + 0.5)
+}
@@ -0,0 +1,110 @@
+package statuspage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+)
+
+func TestSummarize(t *testing.T) {
+	// Test the daily uptimes of a website over 3 days, down on the second one
+	now := time.Date(2020, 1, 3, 12, 0, 0, 0, time.UTC)
+	rollups := []history.Rollup{
+		{Time: time.Date(2019, 12, 31, 12, 0, 0, 0, time.UTC), Count: 10, StatusCodeCount: map[int]int{200: 10}},
+		{Time: time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC), Count: 4, StatusCodeCount: map[int]int{200: 3, 500: 1}},
+	}
+	records := []history.Record{
+		{Time: time.Date(2020, 1, 2, 23, 59, 0, 0, time.UTC), StatusCode: 200},
+		{Time: time.Date(2020, 1, 3, 11, 59, 0, 0, time.UTC), StatusCode: 200},
+	}
+	website := Summarize("API", records, rollups, nil, 3, now)
+
+	if website.State != Operational {
+		t.Errorf("State == %v, want %v", website.State, Operational)
+	}
+	if len(website.Days) != 3 || !website.Days[0].Date.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Days == %v, want 3 days from January 1st", website.Days)
+	}
+	if !math.IsNaN(website.Days[0].Uptime) || website.Days[1].Checks != 5 || website.Days[1].Uptime != 0.8 || website.Days[2].Uptime != 1 {
+		t.Errorf("Days == %v, want no data, then 80%% and 100%% uptimes", website.Days)
+	}
+	if website.Uptime != 5.0/6.0 {
+		t.Errorf("Uptime == %v, want %v", website.Uptime, 5.0/6.0)
+	}
+
+	// An ongoing incident, and a stale website
+	ongoing := []incidents.Incident{{Start: now.Add(-time.Hour)}}
+	if website = Summarize("API", records, nil, ongoing, 3, now); website.State != Down {
+		t.Errorf("State with an ongoing incident == %v, want %v", website.State, Down)
+	}
+	if website = Summarize("API", records, nil, nil, 3, now.Add(2*time.Hour)); website.State != Unknown {
+		t.Errorf("State 2 hours after the last check == %v, want %v", website.State, Unknown)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	// Test the page and feeds of a website recovering from an incident
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	defer os.RemoveAll(dir)
+	store, err := history.Open(filepath.Join(dir, "history.db"), history.DefaultRetention)
+	if err != nil {
+		t.Fatalf("Open returned %v", err)
+	}
+	now := time.Now()
+	url := "https://api.example.com/health?key=****"
+	store.Append(history.Record{URL: url, Time: now.Add(-3 * time.Minute), StatusCode: 500, Reason: "500 Internal Server Error"})
+	store.Append(history.Record{URL: url, Time: now.Add(-time.Minute), StatusCode: 200})
+	store.SaveIncident(incidents.Incident{URL: url, Start: now.Add(-3 * time.Minute), End: now.Add(-time.Minute), FirstFailure: "500 Internal Server Error"})
+	store.Close()
+	if store, err = history.OpenReadOnly(filepath.Join(dir, "history.db")); err != nil {
+		t.Fatalf("OpenReadOnly returned %v", err)
+	}
+	defer store.Close()
+
+	config := Config{Title: "Example <Status>", URL: "https://status.example.com/", Groups: []GroupConfig{{Name: "Services", Websites: []WebsiteConfig{{URL: url, Name: "Public API"}}}}}
+	page, err := Build(store, config, 90, now)
+	if err != nil {
+		t.Fatalf("Build returned %v", err)
+	}
+	if page.State() != Operational || len(page.Incidents) != 1 || len(page.Feed) != 1 || len(page.Groups[0].Websites[0].Days) != 90 {
+		t.Fatalf("Build() == %+v, want an operational website with 1 incident", page)
+	}
+	out := filepath.Join(dir, "status")
+	if err := Generate(out, page); err != nil {
+		t.Fatalf("Generate returned %v", err)
+	}
+
+	html, _ := ioutil.ReadFile(filepath.Join(out, IndexFile))
+	for _, want := range []string{"Example &lt;Status&gt;", "All systems operational", "Public API", "was down", "50.00%"} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("%s does not contain %q", IndexFile, want)
+		}
+	}
+	// Neither the URL nor the failure reason are public
+	for _, secret := range []string{"api.example.com", "Internal Server Error"} {
+		if strings.Contains(string(html), secret) {
+			t.Errorf("%s contains %q", IndexFile, secret)
+		}
+	}
+
+	var feed atomFeed
+	atom, _ := ioutil.ReadFile(filepath.Join(out, AtomFile))
+	if err := xml.Unmarshal(atom, &feed); err != nil || len(feed.Entries) != 1 || feed.Entries[0].Title != "Public API was down" {
+		t.Errorf("%s == %+v, %v, want the incident", AtomFile, feed, err)
+	}
+	var rss rssFeed
+	var buffer bytes.Buffer
+	WriteRSS(&buffer, page)
+	if err := xml.Unmarshal(buffer.Bytes(), &rss); err != nil || len(rss.Items) != 1 || rss.Items[0].GUID.Value != feed.Entries[0].ID {
+		t.Errorf("RSS feed == %+v, %v, want the incident with the Atom id", rss, err)
+	}
+}
@@ -0,0 +1,326 @@
+package statuspage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Files of a generated status page
+const (
+	IndexFile = "index.html"
+	AtomFile  = "incidents.atom"
+	RSSFile   = "incidents.rss"
+)
+
+// pageTemplate is the HTML template of a status page
+var pageTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"uptime":   formatUptime,
+	"color":    uptimeColor,
+	"date":     func(t time.Time) string { return t.Format("Jan 2, 2006") },
+	"datetime": func(t time.Time) string { return t.Format("Jan 2, 2006 15:04 MST") },
+	"duration": formatDuration,
+	"stateText": func(state string) string {
+		return map[string]string{Operational: "Operational", Down: "Down", Unknown: "No data"}[state]
+	},
+	"overallText": func(state string) string {
+		return map[string]string{Operational: "All systems operational", Down: "Some systems are down", Unknown: "Some systems have no recent data"}[state]
+	},
+	"ongoing": func(incidents []Incident) bool {
+		for _, incident := range incidents {
+			if incident.Ongoing() {
+				return true
+			}
+		}
+		return false
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="alternate" type="application/atom+xml" title="{{.Title}} incidents" href="` + AtomFile + `">
+<link rel="alternate" type="application/rss+xml" title="{{.Title}} incidents" href="` + RSSFile + `">
+<style>
+	body { margin: 0 auto; max-width: 860px; padding: 24px 16px; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; background: #fafafa; }
+	h1 { font-size: 26px; margin: 0 0 20px; }
+	h2 { font-size: 18px; margin: 32px 0 12px; }
+	.banner { padding: 14px 18px; border-radius: 6px; color: #fff; font-size: 18px; }
+	.banner.operational { background: #2e9d57; }
+	.banner.down { background: #d64541; }
+	.banner.unknown { background: #8a8a8a; }
+	.group { background: #fff; border: 1px solid #e1e1e1; border-radius: 6px; margin-bottom: 16px; }
+	.group h3 { margin: 0; padding: 12px 16px; font-size: 16px; border-bottom: 1px solid #e1e1e1; }
+	.website { padding: 12px 16px; border-bottom: 1px solid #f0f0f0; }
+	.website:last-child { border-bottom: none; }
+	.header { display: flex; justify-content: space-between; margin-bottom: 8px; }
+	.state.operational { color: #2e9d57; }
+	.state.down { color: #d64541; }
+	.state.unknown { color: #8a8a8a; }
+	.bars { display: flex; gap: 2px; height: 32px; }
+	.bars span { flex: 1; border-radius: 2px; }
+	.legend { display: flex; justify-content: space-between; color: #8a8a8a; font-size: 12px; margin-top: 4px; }
+	.incident { background: #fff; border: 1px solid #e1e1e1; border-radius: 6px; padding: 12px 16px; margin-bottom: 8px; }
+	.incident.ongoing { border-color: #d64541; }
+	.muted { color: #8a8a8a; font-size: 14px; }
+	footer { margin-top: 32px; color: #8a8a8a; font-size: 13px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="banner {{.State}}">{{overallText .State}}</div>
+{{range .Groups}}
+<h2>{{.Name}}</h2>
+<div class="group">
+	{{range .Websites}}
+	<div class="website">
+		<div class="header"><strong>{{.Name}}</strong><span class="state {{.State}}">{{stateText .State}}</span></div>
+		<div class="bars">{{range .Days}}<span style="background: {{color .Uptime}}" title="{{date .Date}} : {{uptime .Uptime}}"></span>{{end}}</div>
+		<div class="legend"><span>{{len .Days}} days ago</span><span>{{uptime .Uptime}} uptime</span><span>Today</span></div>
+	</div>
+	{{end}}
+</div>
+{{end}}
+<h2>Incidents</h2>
+{{range .Incidents}}
+<div class="incident{{if .Ongoing}} ongoing{{end}}">
+	<strong>{{.Website}} {{if .Ongoing}}is down{{else}}was down{{end}}</strong>
+	<div class="muted">{{if .Ongoing}}Since {{datetime .Start}}{{else}}{{datetime .Start}} to {{datetime .End}}, {{duration (.Duration $.Generated)}}{{end}}</div>
+</div>
+{{else}}
+<p class="muted">No incidents in the last 14 days.</p>
+{{end}}
+<footer>Updated {{datetime .Generated}} · <a href="` + AtomFile + `">Atom</a> · <a href="` + RSSFile + `">RSS</a></footer>
+</body>
+</html>
+`))
+
+// atomFeed is an Atom feed
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomLink is a link of an Atom feed or entry
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// atomEntry is an entry of an Atom feed
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Link    *atomLink `xml:"link,omitempty"`
+	Updated string    `xml:"updated"`
+	Summary string    `xml:"summary"`
+}
+
+// rssFeed is a RSS 2.0 feed
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Title   string   `xml:"channel>title"`
+	Link    string   `xml:"channel>link"`
+	// Description is required by RSS
+	Description string    `xml:"channel>description"`
+	Items       []rssItem `xml:"channel>item"`
+}
+
+// rssItem is an item of a RSS feed
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// rssGUID is the unique id of a RSS item, which is not a link
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteHTML writes the HTML of a status page
+func WriteHTML(w io.Writer, page Page) error {
+	return pageTemplate.Execute(w, page)
+}
+
+// WriteAtom writes the Atom feed of the incidents of a status page
+func WriteAtom(w io.Writer, page Page) error {
+	feed := atomFeed{Title: page.Title + " incidents", ID: feedID(page), Updated: page.Generated.UTC().Format(time.RFC3339), Author: page.Title}
+	if page.URL != "" {
+		feed.Link = &atomLink{Href: page.URL}
+	}
+	for _, incident := range page.Feed {
+		entry := atomEntry{
+			Title:   incidentTitle(incident),
+			ID:      incidentID(incident),
+			Updated: incidentUpdated(incident).UTC().Format(time.RFC3339),
+			Summary: incidentSummary(incident, page.Generated),
+		}
+		if page.URL != "" {
+			entry.Link = &atomLink{Href: page.URL}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return writeXML(w, feed)
+}
+
+// WriteRSS writes the RSS feed of the incidents of a status page
+func WriteRSS(w io.Writer, page Page) error {
+	feed := rssFeed{Version: "2.0", Title: page.Title + " incidents", Link: page.URL, Description: "Incidents of " + page.Title}
+	for _, incident := range page.Feed {
+		feed.Items = append(feed.Items, rssItem{
+			Title:       incidentTitle(incident),
+			Link:        page.URL,
+			Description: incidentSummary(incident, page.Generated),
+			GUID:        rssGUID{IsPermaLink: "false", Value: incidentID(incident)},
+			PubDate:     incidentUpdated(incident).UTC().Format(time.RFC1123Z),
+		})
+	}
+	return writeXML(w, feed)
+}
+
+// Generate writes the HTML and the feeds of a status page in a directory, which is created if needed
+func Generate(dir string, page Page) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, write := range map[string]func(io.Writer, Page) error{IndexFile: WriteHTML, AtomFile: WriteAtom, RSSFile: WriteRSS} {
+		if err := writeFile(filepath.Join(dir, name), page, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes a file of a status page through a temporary file, so that it is never served half written
+func writeFile(path string, page Page, write func(io.Writer, Page) error) error {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if err = write(file, page); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// writeXML writes an XML document
+func writeXML(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(document)
+}
+
+// feedID returns the unique id of the feed of a status page
+func feedID(page Page) string {
+	if page.URL != "" {
+		return page.URL
+	}
+	return "urn:webmonitor:" + slug(page.Title)
+}
+
+// incidentID returns the unique id of an incident, which is kept once it is resolved
+func incidentID(incident Incident) string {
+	return fmt.Sprintf("urn:webmonitor:incident:%s:%d", slug(incident.Website), incident.Start.Unix())
+}
+
+// incidentTitle returns the title of an incident
+func incidentTitle(incident Incident) string {
+	if incident.Ongoing() {
+		return incident.Website + " is down"
+	}
+	return incident.Website + " was down"
+}
+
+// incidentSummary describes an incident
+func incidentSummary(incident Incident, now time.Time) string {
+	if incident.Ongoing() {
+		return fmt.Sprintf("%s is down since %s.", incident.Website, incident.Start.UTC().Format(time.RFC1123))
+	}
+	return fmt.Sprintf("%s was down from %s to %s, for %s.", incident.Website, incident.Start.UTC().Format(time.RFC1123), incident.End.UTC().Format(time.RFC1123), formatDuration(incident.Duration(now)))
+}
+
+// incidentUpdated returns when an incident was last updated
+func incidentUpdated(incident Incident) time.Time {
+	if incident.Ongoing() {
+		return incident.Start
+	}
+	return incident.End
+}
+
+// slug returns a lower case identifier of a name, made of letters, digits and dashes
+func slug(name string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name), "-")
+}
+
+// formatUptime formats an uptime as a percentage
+func formatUptime(uptime float64) string {
+	if math.IsNaN(uptime) {
+		return "No data"
+	}
+	if uptime == 1 {
+		return "100%"
+	}
+	return fmt.Sprintf("%.2f%%", math.Floor(uptime*10000)/100)
+}
+
+// uptimeColor returns the color of the uptime bar of a day
+func uptimeColor(uptime float64) template.CSS {
+	switch {
+	case math.IsNaN(uptime):
+		return "#d9d9d9"
+	case uptime >= 0.999:
+		return "#2e9d57"
+	case uptime >= 0.99:
+		return "#9cc74b"
+	case uptime >= 0.95:
+		return "#f0a030"
+	}
+	return "#d64541"
+}
+
+// formatDuration formats a duration rounded to the minute, such as 2h5min
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dmin", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%dmin", hours, minutes)
+}
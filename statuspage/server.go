@@ -0,0 +1,79 @@
+package statuspage
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/history"
+)
+
+// cacheDuration is how long a built status page is served before being built again from the history
+const cacheDuration = time.Minute
+
+// Server serves a status page and its feeds, built from a history file
+type Server struct {
+	mutex sync.Mutex
+	// Path of the history file, opened while the page is built so that webmonitor can keep writing it
+	path   string
+	config Config
+	days   int
+	page   Page
+}
+
+// NewServer returns a new Server of the status page of a history file over the last days
+func NewServer(path string, config Config, days int) *Server {
+	return &Server{path: path, config: config, days: days}
+}
+
+// ServeHTTP serves the status page at /, and its feeds
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var write func(io.Writer, Page) error
+	switch r.URL.Path {
+	case "/", "/" + IndexFile:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		write = WriteHTML
+	case "/" + AtomFile:
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		write = WriteAtom
+	case "/" + RSSFile:
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		write = WriteRSS
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	page, err := s.build()
+	if err != nil {
+		http.Error(w, "the status page could not be built", http.StatusInternalServerError)
+		return
+	}
+	var buffer bytes.Buffer
+	if err := write(&buffer, page); err != nil {
+		http.Error(w, "the status page could not be written", http.StatusInternalServerError)
+		return
+	}
+	buffer.WriteTo(w)
+}
+
+// build returns the status page, built again from the history once it is older than the cache duration
+func (s *Server) build() (Page, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if time.Since(s.page.Generated) < cacheDuration {
+		return s.page, nil
+	}
+	store, err := history.OpenReadOnly(s.path)
+	if err != nil {
+		return Page{}, err
+	}
+	page, err := Build(store, s.config, s.days, time.Now())
+	store.Close()
+	if err != nil {
+		return page, err
+	}
+	s.page = page
+	return page, nil
+}
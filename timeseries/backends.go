@@ -0,0 +1,259 @@
+package timeseries
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxDatagramSize is the largest StatsD datagram sent, fitting in an Ethernet frame
+const maxDatagramSize = 1432
+
+// dialTimeout is how long to wait for a Graphite connection, or an InfluxDB response
+const dialTimeout = 5 * time.Second
+
+// Backend sends batches of points to a time series database
+type Backend interface {
+	// Send sends a batch of points. It returns an error if some of them may not have been sent, so that the batch is sent again,
+	// or a *PartialError if only the first ones were sent.
+	Send(points []Point) error
+	Close() error
+}
+
+// PartialError is the failure of a backend which sent the first points of a batch, which are not sent again
+type PartialError struct {
+	Sent int
+	Err  error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error()
+}
+
+// InfluxHTTP sends points to an InfluxDB write endpoint in the line protocol
+type InfluxHTTP struct {
+	url string
+	// Authorization header of the requests, such as "Token xxx", if any
+	authorization string
+	client        http.Client
+}
+
+// NewInfluxHTTP returns a new InfluxHTTP backend of a write endpoint, such as http://localhost:8086/api/v2/write?org=o&bucket=b
+func NewInfluxHTTP(url string, authorization string) *InfluxHTTP {
+	return &InfluxHTTP{url: url, authorization: authorization, client: http.Client{Timeout: dialTimeout}}
+}
+
+// Send writes points to the InfluxDB endpoint
+func (b *InfluxHTTP) Send(points []Point) error {
+	var body bytes.Buffer
+	writeLineProtocol(&body, points)
+	req, err := http.NewRequest(http.MethodPost, b.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if b.authorization != "" {
+		req.Header.Set("Authorization", b.authorization)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB answered %s : %s", resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// Close does nothing, as the requests are not kept open
+func (b *InfluxHTTP) Close() error {
+	return nil
+}
+
+// InfluxFile appends points to a file in the line protocol, such as for Telegraf to tail it
+type InfluxFile struct {
+	path string
+}
+
+// NewInfluxFile returns a new InfluxFile backend of a file, which is created if needed
+func NewInfluxFile(path string) *InfluxFile {
+	return &InfluxFile{path: path}
+}
+
+// Send appends points to the file. The file is opened for each batch, so that it can be rotated.
+func (b *InfluxFile) Send(points []Point) error {
+	file, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	var buffer bytes.Buffer
+	writeLineProtocol(&buffer, points)
+	if _, err = buffer.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Close does nothing, as the file is not kept open
+func (b *InfluxFile) Close() error {
+	return nil
+}
+
+// writeLineProtocol writes points in the line protocol, one per line
+func writeLineProtocol(w *bytes.Buffer, points []Point) {
+	for _, point := range points {
+		w.WriteString(point.LineProtocol())
+		w.WriteByte('\n')
+	}
+}
+
+// StatsD sends points to a StatsD server over UDP. Response times of checks are timers, status codes are counters, and the other fields are gauges.
+type StatsD struct {
+	address string
+	prefix  string
+	conn    net.Conn
+}
+
+// NewStatsD returns a new StatsD backend of a server address, such as localhost:8125, naming the metrics with a prefix
+func NewStatsD(address string, prefix string) *StatsD {
+	return &StatsD{address: address, prefix: prefix}
+}
+
+// Send sends points to the StatsD server, in datagrams of several metrics
+func (b *StatsD) Send(points []Point) error {
+	if b.conn == nil {
+		conn, err := net.Dial("udp", b.address)
+		if err != nil {
+			return err
+		}
+		b.conn = conn
+	}
+	var datagram bytes.Buffer
+	for _, point := range points {
+		for _, metric := range statsdMetrics(b.prefix, point) {
+			if datagram.Len() > 0 && datagram.Len()+1+len(metric) > maxDatagramSize {
+				if _, err := b.conn.Write(datagram.Bytes()); err != nil {
+					return err
+				}
+				datagram.Reset()
+			}
+			if datagram.Len() > 0 {
+				datagram.WriteByte('\n')
+			}
+			datagram.WriteString(metric)
+		}
+	}
+	if datagram.Len() > 0 {
+		_, err := b.conn.Write(datagram.Bytes())
+		return err
+	}
+	return nil
+}
+
+// Close closes the UDP socket
+func (b *StatsD) Close() error {
+	if b.conn == nil {
+		return nil
+	}
+	return b.conn.Close()
+}
+
+// statsdMetrics returns the StatsD metrics of a point, such as webmonitor.example_com.check.response_time_ms:120|ms
+func statsdMetrics(prefix string, point Point) []string {
+	metrics := make([]string, 0, len(point.Fields))
+	for _, field := range fieldKeys(point.Fields) {
+		value := formatValue(point.Fields[field], false)
+		switch {
+		case point.Measurement == CheckMeasurement && field == "response_time_ms":
+			metrics = append(metrics, metricPath(prefix, point, field)+":"+value+"|ms")
+		case point.Measurement == CheckMeasurement && field == "status_code":
+			metrics = append(metrics, metricPath(prefix, point, "status_"+value)+":1|c")
+		case point.Measurement == CheckMeasurement && field == "success":
+			// Successes are counted by status code
+		default:
+			metrics = append(metrics, metricPath(prefix, point, field)+":"+value+"|g")
+		}
+	}
+	return metrics
+}
+
+// Graphite sends points to a Graphite server over TCP, in the plaintext protocol
+type Graphite struct {
+	address string
+	prefix  string
+	conn    net.Conn
+	// Lines left unwritten by a failure, from the line it cut, written first by the next Send
+	unsent []byte
+}
+
+// NewGraphite returns a new Graphite backend of a server address, such as localhost:2003, naming the metrics with a prefix
+func NewGraphite(address string, prefix string) *Graphite {
+	return &Graphite{address: address, prefix: prefix}
+}
+
+// Send sends points to the Graphite server. The connection is opened again after a failure.
+// Once some lines of the points are written, a failure keeps the others to be written by the next Send, rather than
+// sending the points again : a line cut by the failure is written again whole, the server dropping the cut one.
+func (b *Graphite) Send(points []Point) error {
+	if b.conn == nil {
+		conn, err := net.DialTimeout("tcp", b.address, dialTimeout)
+		if err != nil {
+			return err
+		}
+		b.conn = conn
+	}
+	buffer := bytes.NewBuffer(b.unsent)
+	previous := len(b.unsent)
+	for _, point := range points {
+		timestamp := strconv.FormatInt(point.Time.Unix(), 10)
+		for _, field := range fieldKeys(point.Fields) {
+			buffer.WriteString(metricPath(b.prefix, point, field) + " " + formatValue(point.Fields[field], false) + " " + timestamp + "\n")
+		}
+	}
+	lines := buffer.Bytes()
+	b.conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+	written, err := b.conn.Write(lines)
+	if err == nil {
+		b.unsent = nil
+		return nil
+	}
+	b.conn.Close()
+	b.conn = nil
+	// Keeping the lines from the first one not written whole
+	cut := bytes.LastIndexByte(lines[:written], '\n') + 1
+	if cut < previous {
+		// None of the points was written, they are sent again
+		b.unsent = append([]byte(nil), lines[cut:previous]...)
+		return err
+	}
+	b.unsent = append([]byte(nil), lines[cut:]...)
+	return &PartialError{Sent: len(points), Err: err}
+}
+
+// Close closes the connection to the Graphite server. Lines kept by a failure are dropped.
+func (b *Graphite) Close() error {
+	if b.conn == nil {
+		return nil
+	}
+	return b.conn.Close()
+}
+
+// fieldKeys returns the names of the fields of a point, sorted
+func fieldKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package timeseries
+
+import (
+	"sync"
+	"time"
+)
+
+// Failure notifies that an exporter failed to send its points, or recovered if Err is nil
+type Failure struct {
+	Exporter string
+	Err      error
+}
+
+// Exporter sends points to a backend in batches from a goroutine. Points are buffered while the backend fails, up to a
+// buffer size beyond which the oldest ones are dropped.
+type Exporter struct {
+	name          string
+	backend       Backend
+	batchSize     int
+	bufferSize    int
+	flushInterval time.Duration
+	failures      chan<- Failure
+	mutex         sync.Mutex
+	// Points waiting to be sent, oldest first
+	pending []Point
+	// Signals the goroutine that a batch is full
+	full chan struct{}
+	done chan struct{}
+	// Closed once the goroutine flushed the last points
+	stopped chan struct{}
+}
+
+// NewExporter returns a new Exporter of a backend and starts it. Its failures and recoveries are sent on the failures
+// channel, if any, without blocking.
+func NewExporter(name string, backend Backend, batchSize int, bufferSize int, flushInterval time.Duration, failures chan<- Failure) *Exporter {
+	if bufferSize < batchSize {
+		bufferSize = batchSize
+	}
+	e := &Exporter{
+		name:          name,
+		backend:       backend,
+		batchSize:     batchSize,
+		bufferSize:    bufferSize,
+		flushInterval: flushInterval,
+		failures:      failures,
+		full:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Add queues points to be sent with the next batch, without blocking
+func (e *Exporter) Add(points ...Point) {
+	e.mutex.Lock()
+	e.pending = append(e.pending, points...)
+	e.trim()
+	full := len(e.pending) >= e.batchSize
+	e.mutex.Unlock()
+	if full {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close sends the pending points a last time, and closes the backend
+func (e *Exporter) Close() error {
+	close(e.done)
+	<-e.stopped
+	return e.backend.Close()
+}
+
+// run flushes the pending points when a batch is full or at each flush interval, until the exporter is closed
+func (e *Exporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		select {
+		case <-e.full:
+		case <-ticker.C:
+		case <-e.done:
+			e.flush()
+			return
+		}
+		err := e.flush()
+		if failing != (err != nil) {
+			failing = err != nil
+			e.notify(err)
+		}
+	}
+}
+
+// flush sends the pending points in batches, and stops at the first failure, keeping the points of the batch not sent for the next flush
+func (e *Exporter) flush() error {
+	for {
+		e.mutex.Lock()
+		size := len(e.pending)
+		if size > e.batchSize {
+			size = e.batchSize
+		}
+		batch := e.pending[:size:size]
+		e.pending = e.pending[size:]
+		e.mutex.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := e.backend.Send(batch); err != nil {
+			if partial, ok := err.(*PartialError); ok {
+				batch = batch[partial.Sent:]
+			}
+			// Putting the batch back before the points added meanwhile
+			e.mutex.Lock()
+			e.pending = append(batch, e.pending...)
+			e.trim()
+			e.mutex.Unlock()
+			return err
+		}
+	}
+}
+
+// trim drops the oldest pending points beyond the buffer size. The mutex should be held.
+func (e *Exporter) trim() {
+	if len(e.pending) > e.bufferSize {
+		e.pending = append([]Point(nil), e.pending[len(e.pending)-e.bufferSize:]...)
+	}
+}
+
+// notify sends a failure or recovery of the exporter, if it can be received
+func (e *Exporter) notify(err error) {
+	if e.failures == nil {
+		return
+	}
+	select {
+	case e.failures <- Failure{Exporter: e.name, Err: err}:
+	default:
+	}
+}
@@ -0,0 +1,284 @@
+package timeseries
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// checkTime is the time of the test points, 1577836800 seconds since the epoch
+var checkTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testPoints returns a check point and a statistics point of a website with tags
+func testPoints() []Point {
+	average, availability := 150.5, 0.75
+	tags := map[string]string{"env": "prod", "team name": "web", "url": "ignored"}
+	return []Point{
+		CheckPoint("https://example.com/?token=****", tags, checkTime, 120, 200),
+		StatsPoint("https://example.com/?token=****", tags, "10m", checkTime, statistics.Snapshot{Checks: 4, Average: &average, Availability: &availability}),
+	}
+}
+
+func TestLineProtocol(t *testing.T) {
+	points := testPoints()
+	for i, want := range []string{
+		`webmonitor_check,env=prod,team\ name=web,url=https://example.com/?token\=**** response_time_ms=120i,status_code=200i,success=1i 1577836800000000000`,
+		`webmonitor_stats,env=prod,team\ name=web,timeframe=10m,url=https://example.com/?token\=**** availability=0.75,average_ms=150.5,checks=4i 1577836800000000000`,
+	} {
+		if line := points[i].LineProtocol(); line != want {
+			t.Errorf("LineProtocol() == %q, want %q", line, want)
+		}
+	}
+}
+
+func TestInfluxHTTP(t *testing.T) {
+	var body, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := ioutil.ReadAll(r.Body)
+		body, authorization = string(content), r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	backend := NewInfluxHTTP(server.URL+"/api/v2/write?bucket=webmonitor", "Token secret")
+	if err := backend.Send(testPoints()); err != nil {
+		t.Fatalf("Send returned %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(body), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "webmonitor_check,") {
+		t.Errorf("body == %q, want the 2 points", body)
+	}
+	if authorization != "Token secret" {
+		t.Errorf("Authorization == %q, want %q", authorization, "Token secret")
+	}
+
+	// Rejected points are an error
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized access", http.StatusUnauthorized)
+	}))
+	defer rejecting.Close()
+	if err := NewInfluxHTTP(rejecting.URL, "").Send(testPoints()); err == nil || !strings.Contains(err.Error(), "unauthorized access") {
+		t.Errorf("Send to a rejecting server returned %v, want its message", err)
+	}
+}
+
+func TestInfluxFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "points.lp")
+	backend := NewInfluxFile(path)
+	for i := 0; i < 2; i++ {
+		if err := backend.Send(testPoints()); err != nil {
+			t.Fatalf("Send returned %v", err)
+		}
+	}
+	content, _ := ioutil.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 4 {
+		t.Errorf("file == %q, want the 4 points appended", content)
+	}
+}
+
+func TestStatsD(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket returned %v", err)
+	}
+	defer listener.Close()
+	backend := NewStatsD(listener.LocalAddr().String(), "webmonitor")
+	defer backend.Close()
+	if err := backend.Send(testPoints()); err != nil {
+		t.Fatalf("Send returned %v", err)
+	}
+	buffer := make([]byte, maxDatagramSize)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buffer)
+	if err != nil {
+		t.Fatalf("ReadFrom returned %v", err)
+	}
+	want := strings.Join([]string{
+		"webmonitor.example_com_token.check.response_time_ms:120|ms",
+		"webmonitor.example_com_token.check.status_200:1|c",
+		"webmonitor.example_com_token.stats.10m.availability:0.75|g",
+		"webmonitor.example_com_token.stats.10m.average_ms:150.5|g",
+		"webmonitor.example_com_token.stats.10m.checks:4|g",
+	}, "\n")
+	if datagram := string(buffer[:n]); datagram != want {
+		t.Errorf("datagram == %q, want %q", datagram, want)
+	}
+}
+
+func TestGraphite(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned %v", err)
+	}
+	defer listener.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	backend := NewGraphite(listener.Addr().String(), "")
+	defer backend.Close()
+	if err := backend.Send(testPoints()); err != nil {
+		t.Fatalf("Send returned %v", err)
+	}
+	for _, want := range []string{
+		"example_com_token.check.response_time_ms 120 1577836800",
+		"example_com_token.check.status_code 200 1577836800",
+		"example_com_token.check.success 1 1577836800",
+		"example_com_token.stats.10m.availability 0.75 1577836800",
+	} {
+		select {
+		case line := <-lines:
+			if line != want {
+				t.Errorf("line == %q, want %q", line, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("line %q not received", want)
+		}
+	}
+}
+
+// cutConn is a connection writing its first bytes only, then failing
+type cutConn struct {
+	net.Conn
+	size int
+}
+
+func (c cutConn) Write(b []byte) (int, error) {
+	return c.size, errors.New("connection reset by peer")
+}
+
+func (c cutConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (c cutConn) Close() error {
+	return nil
+}
+
+func TestGraphitePartialWrite(t *testing.T) {
+	// Test if the lines not written by a failure are written by the next Send, from the line it cut, and the points not sent again
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned %v", err)
+	}
+	defer listener.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	backend := NewGraphite(listener.Addr().String(), "")
+	defer backend.Close()
+	// Cutting the second line
+	backend.conn = cutConn{size: 70}
+	err = backend.Send(testPoints())
+	if partial, ok := err.(*PartialError); !ok || partial.Sent != 2 {
+		t.Fatalf("Send returned %v, want a partial error of the 2 points", err)
+	}
+	if err := backend.Send(testPoints()[:1]); err != nil {
+		t.Fatalf("Send returned %v", err)
+	}
+	for _, want := range []string{
+		"example_com_token.check.status_code 200 1577836800",
+		"example_com_token.check.success 1 1577836800",
+		"example_com_token.stats.10m.availability 0.75 1577836800",
+		"example_com_token.stats.10m.average_ms 150.5 1577836800",
+		"example_com_token.stats.10m.checks 4 1577836800",
+		"example_com_token.check.response_time_ms 120 1577836800",
+		"example_com_token.check.status_code 200 1577836800",
+		"example_com_token.check.success 1 1577836800",
+	} {
+		select {
+		case line := <-lines:
+			if line != want {
+				t.Errorf("line == %q, want %q", line, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("line %q not received", want)
+		}
+	}
+	select {
+	case line := <-lines:
+		t.Errorf("line %q received, want no more lines", line)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// flakyBackend fails while down, and records the points it receives otherwise
+type flakyBackend struct {
+	down   chan bool
+	points chan []Point
+}
+
+func (b *flakyBackend) Send(points []Point) error {
+	if <-b.down {
+		return errors.New("connection refused")
+	}
+	b.points <- points
+	return nil
+}
+
+func (b *flakyBackend) Close() error {
+	return nil
+}
+
+func TestExporter(t *testing.T) {
+	backend := &flakyBackend{down: make(chan bool), points: make(chan []Point, 10)}
+	failures := make(chan Failure, 10)
+	// Points are sent when a batch of 2 is full, and up to 3 of them are buffered
+	e := NewExporter("influxdb", backend, 2, 3, time.Hour, failures)
+	point := testPoints()[0]
+
+	// The backend fails, the batch is kept
+	e.Add(point, point)
+	backend.down <- true
+	if failure := <-failures; failure.Exporter != "influxdb" || failure.Err == nil {
+		t.Errorf("failure == %+v, want the error of influxdb", failure)
+	}
+	// The backend recovers, the buffered points are sent in batches, without the oldest one beyond the buffer size
+	e.Add(point, point)
+	backend.down <- false
+	backend.down <- false
+	if failure := <-failures; failure.Err != nil {
+		t.Errorf("failure == %+v, want a recovery", failure)
+	}
+	if batch := <-backend.points; len(batch) != 2 {
+		t.Errorf("batch == %v, want 2 points", batch)
+	}
+	if batch := <-backend.points; len(batch) != 1 {
+		t.Errorf("batch == %v, want 1 point", batch)
+	}
+
+	// The last points are sent when closing
+	e.Add(point)
+	go func() { backend.down <- false }()
+	e.Close()
+	if batch := <-backend.points; len(batch) != 1 {
+		t.Errorf("batch == %v, want the last point", batch)
+	}
+}
@@ -0,0 +1,148 @@
+package timeseries
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// Measurements of the points
+const (
+	CheckMeasurement = "webmonitor_check"
+	StatsMeasurement = "webmonitor_stats"
+)
+
+// reservedTags are the tag names of the points, which website tags cannot override
+var reservedTags = map[string]bool{"url": true, "timeframe": true}
+
+// Point is a measurement of a website at a given time, with integer or float fields
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]interface{}
+	Time        time.Time
+}
+
+// CheckPoint returns the point of a check of a website. Its URL label should hold no secret.
+func CheckPoint(label string, tags map[string]string, t time.Time, responseTime int, statusCode int) Point {
+	success := 0
+	if statusCode == 200 {
+		success = 1
+	}
+	return Point{
+		Measurement: CheckMeasurement,
+		Tags:        pointTags(label, tags),
+		Fields:      map[string]interface{}{"response_time_ms": responseTime, "status_code": statusCode, "success": success},
+		Time:        t,
+	}
+}
+
+// StatsPoint returns the point of the statistics of a website over a timeframe, such as 10min. Statistics without value are left out.
+func StatsPoint(label string, tags map[string]string, timeframe string, t time.Time, snapshot statistics.Snapshot) Point {
+	point := Point{Measurement: StatsMeasurement, Tags: pointTags(label, tags), Fields: map[string]interface{}{"checks": snapshot.Checks}, Time: t}
+	point.Tags["timeframe"] = timeframe
+	for name, value := range map[string]*float64{
+		"average_ms":   snapshot.Average,
+		"min_ms":       snapshot.Min,
+		"max_ms":       snapshot.Max,
+		"p50_ms":       snapshot.P50,
+		"p90_ms":       snapshot.P90,
+		"p95_ms":       snapshot.P95,
+		"p99_ms":       snapshot.P99,
+		"availability": snapshot.Availability,
+		"apdex":        snapshot.Apdex,
+	} {
+		if value != nil && !math.IsNaN(*value) {
+			point.Fields[name] = *value
+		}
+	}
+	return point
+}
+
+// pointTags returns the tags of the points of a website
+func pointTags(label string, tags map[string]string) map[string]string {
+	pointTags := map[string]string{"url": label}
+	for name, value := range tags {
+		if !reservedTags[name] {
+			pointTags[name] = value
+		}
+	}
+	return pointTags
+}
+
+// tagKeys returns the names of the tags of a point, sorted
+func tagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lineProtocolEscaper escapes the measurements, tags and field keys of the InfluxDB line protocol
+var lineProtocolEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// LineProtocol returns a point in the InfluxDB line protocol, with a nanosecond timestamp
+func (p Point) LineProtocol() string {
+	var line strings.Builder
+	line.WriteString(lineProtocolEscaper.Replace(p.Measurement))
+	for _, key := range tagKeys(p.Tags) {
+		if p.Tags[key] == "" {
+			// Empty tag values are not allowed
+			continue
+		}
+		line.WriteString("," + lineProtocolEscaper.Replace(key) + "=" + lineProtocolEscaper.Replace(p.Tags[key]))
+	}
+	for index, key := range fieldKeys(p.Fields) {
+		separator := ","
+		if index == 0 {
+			separator = " "
+		}
+		line.WriteString(separator + lineProtocolEscaper.Replace(key) + "=" + formatValue(p.Fields[key], true))
+	}
+	line.WriteString(" " + strconv.FormatInt(p.Time.UnixNano(), 10))
+	return line.String()
+}
+
+// formatValue formats an integer or float field value. Integers are suffixed with i in the InfluxDB line protocol.
+func formatValue(value interface{}, lineProtocol bool) string {
+	switch value := value.(type) {
+	case int:
+		if lineProtocol {
+			return strconv.Itoa(value) + "i"
+		}
+		return strconv.Itoa(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return "0"
+}
+
+// invalidPathCharacters are the characters replaced in the StatsD and Graphite metric paths
+var invalidPathCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// urlScheme matches the scheme and www prefix of a URL
+var urlScheme = regexp.MustCompile(`^(https?://)?(www\.)?`)
+
+// pathElement returns a value as an element of a StatsD or Graphite metric path, such as example_com_health
+func pathElement(value string) string {
+	return strings.Trim(invalidPathCharacters.ReplaceAllString(urlScheme.ReplaceAllString(value, ""), "_"), "_")
+}
+
+// metricPath returns the path of a metric of a point, such as webmonitor.example_com.stats.10min.p95_ms
+func metricPath(prefix string, p Point, field string) string {
+	elements := []string{pathElement(p.Tags["url"]), strings.TrimPrefix(p.Measurement, "webmonitor_")}
+	if timeframe, ok := p.Tags["timeframe"]; ok {
+		elements = append(elements, pathElement(timeframe))
+	}
+	if prefix != "" {
+		elements = append([]string{prefix}, elements...)
+	}
+	return strings.Join(append(elements, field), ".")
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/api"
+	"github.com/hugo-sv/webmonitor/catalog"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/contents"
+	"github.com/hugo-sv/webmonitor/dashboard"
+	"github.com/hugo-sv/webmonitor/display"
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/metrics"
+	"github.com/hugo-sv/webmonitor/monitor"
+	"github.com/hugo-sv/webmonitor/statistics"
+)
+
+// website is the state of a monitored website
+type website struct {
+	// Id of the website, never reused within a run. It is the generation of its checks, so that the results of a website
+	// removed and added again under the same URL, while it was being checked, are told apart.
+	id     int
+	config cli.Website
+	// Statistics of each timeframe, and of the alerts timeframe
+	statistics     []*statistics.Statistic
+	alertStatistic *statistics.Statistic
+	objectives     []*statistics.Objective
+	// Anomaly detector and content watcher, nil if disabled
+	detector *statistics.AnomalyDetector
+	watcher  *contents.Watcher
+	// Commands of the goroutine checking the website
+	commands chan monitor.Command
+	paused   bool
+}
+
+// registry keeps track of the monitored websites, which can be added and removed while monitoring.
+// It rebuilds them from the history, and gives a snapshot of them to the metrics exporter, API and dashboard, if any, each time they change.
+type registry struct {
+	timeframes   []time.Duration
+	timeout      int
+	statsMessage chan monitor.CheckStats
+	store        *history.Store
+	tracker      *incidents.Tracker
+	// Incidents restored from the history are those which started within this window, the raw retention of the history
+	incidentsWindow time.Duration
+	exporter        *metrics.Exporter
+	apiServer       *api.Server
+	dashboard       *dashboard.Server
+	// Whether the checks are traced, to export their spans
+	tracing bool
+	// Monitored URLs, in the order they were added
+	urls     []string
+	websites map[string]*website
+	// URLs added through the API which are being loaded from the history
+	loading map[string]bool
+	nextID  int
+}
+
+// loadedWebsite is a website added through the API, once loaded from the history
+type loadedWebsite struct {
+	command   api.Command
+	site      *website
+	incidents []incidents.Incident
+	err       error
+}
+
+// add adds a website, rebuilds its statistics and incidents from the history, and starts checking it
+func (r *registry) add(url string, config cli.Website) error {
+	if site, duplicate := r.websites[url]; duplicate {
+		return fmt.Errorf("website %s is already monitored", site.config.Label)
+	}
+	site, urlIncidents, err := r.load(url, config)
+	if err != nil {
+		return err
+	}
+	r.start(url, site, urlIncidents)
+	return nil
+}
+
+// addLater adds a website requested through the API : it is loaded from the history by a goroutine, so that the main loop
+// does not wait for the file, and sent on the loaded channel to be started.
+func (r *registry) addLater(command api.Command, loaded chan<- loadedWebsite) error {
+	if _, duplicate := r.websites[command.URL]; duplicate || r.loading[command.URL] {
+		return fmt.Errorf("website %s is already monitored", command.Website.Label)
+	}
+	r.loading[command.URL] = true
+	go func() {
+		site, urlIncidents, err := r.load(command.URL, command.Website)
+		loaded <- loadedWebsite{command: command, site: site, incidents: urlIncidents, err: err}
+	}()
+	return nil
+}
+
+// load builds a website, and rebuilds its statistics and incidents from the history. It only reads the registry settings.
+func (r *registry) load(url string, config cli.Website) (*website, []incidents.Incident, error) {
+	site := &website{config: config, commands: make(chan monitor.Command, 1)}
+	// Keeping track of the records of each timeframe
+	for _, timeframe := range r.timeframes {
+		site.statistics = append(site.statistics, statistics.NewStatistic(timeframe, config.ApdexTarget))
+	}
+	// Alerts are handled with the 2 min timeframe stats
+	site.alertStatistic = statistics.NewStatistic(alertTimeframe, config.ApdexTarget)
+	for _, slo := range config.SLOs {
+		site.objectives = append(site.objectives, statistics.NewObjective(slo.Name, slo.Objective/100.0, slo.Threshold, slo.WindowDuration))
+	}
+	if config.Anomaly != nil {
+		site.detector = statistics.NewAnomalyDetector(config.Anomaly.Alpha, config.Anomaly.Sigmas, config.Anomaly.SustainDuration)
+	}
+	if config.Content != nil {
+		site.watcher = contents.NewWatcher(config.Content.IgnoreRegexp, config.Content.Expected, config.Content.Keep)
+	}
+	// Rebuilding the statistics and incidents from the history, which records the websites by their key so that the file holds no secret
+	if r.store == nil {
+		return site, nil, nil
+	}
+	if err := rebuildStatistics(r.store, config.HistoryKey(), site); err != nil {
+		return nil, nil, err
+	}
+	urlIncidents, err := restoreIncidents(r.store, url, config.HistoryKey(), time.Now().Add(-r.incidentsWindow))
+	if err != nil {
+		return nil, nil, err
+	}
+	return site, urlIncidents, nil
+}
+
+// start registers a loaded website with its incidents, and starts checking it
+func (r *registry) start(url string, site *website, urlIncidents []incidents.Incident) {
+	site.id = r.nextID
+	r.nextID++
+	r.tracker.Restore(urlIncidents)
+	r.urls = append(r.urls, url)
+	r.websites[url] = site
+	r.publish()
+	// Starting a goroutine fetching data for this URL
+	go monitor.CheckOnTicks(monitor.Target{URL: url, Generation: site.id, Headers: site.config.Headers, ReadBody: site.config.Content != nil, Trace: r.tracing}, site.config.Interval, r.timeout, site.commands, r.statsMessage)
+}
+
+// remove stops checking a website and forgets it. It returns the ongoing incident of the website, closed, if any.
+func (r *registry) remove(url string) (incidents.Incident, bool) {
+	site := r.websites[url]
+	site.commands <- monitor.Stop
+	for index := range r.urls {
+		if r.urls[index] == url {
+			r.urls = append(r.urls[:index:index], r.urls[index+1:]...)
+			break
+		}
+	}
+	delete(r.websites, url)
+	r.publish()
+	incident, closed := r.tracker.Close(url, time.Now())
+	r.tracker.Remove(url)
+	return incident, closed
+}
+
+// send sends a command to the goroutine checking a website
+func (r *registry) send(url string, command monitor.Command) {
+	site := r.websites[url]
+	switch command {
+	case monitor.Pause:
+		site.paused = true
+	case monitor.Resume:
+		site.paused = false
+	}
+	r.publish()
+	site.commands <- command
+}
+
+// publish gives a snapshot of the monitored websites to the metrics exporter, API and dashboard, if any
+func (r *registry) publish() {
+	snapshot := make([]catalog.Website, 0, len(r.urls))
+	for _, url := range r.urls {
+		site := r.websites[url]
+		snapshot = append(snapshot, catalog.Website{
+			ID:         site.id,
+			URL:        url,
+			Label:      site.config.Label,
+			Config:     site.config,
+			Paused:     site.paused,
+			Statistics: site.statistics,
+			Objectives: site.objectives,
+		})
+	}
+	if r.exporter != nil {
+		r.exporter.SetWebsites(snapshot)
+	}
+	if r.apiServer != nil {
+		r.apiServer.SetWebsites(snapshot)
+	}
+	if r.dashboard != nil {
+		r.dashboard.SetWebsites(snapshot)
+	}
+}
+
+// stop stops checking every website
+func (r *registry) stop() {
+	for _, site := range r.websites {
+		site.commands <- monitor.Stop
+	}
+}
+
+// updateView updates the websites of the UI. The maps are replaced rather than modified, as renders may be reading them.
+func (r *registry) updateView(uiView *display.View) {
+	uiView.Urls = append([]string(nil), r.urls...)
+	uiView.IDs = make(map[string]int)
+	uiView.Labels = make(map[string]string)
+	uiView.URLStatistics = make(map[string][]*statistics.Statistic)
+	uiView.URLObjectives = make(map[string][]*statistics.Objective)
+	uiView.Contents = make(map[string]*contents.Watcher)
+	uiView.Tags = make(map[string]map[string]string)
+	for url, site := range r.websites {
+		uiView.IDs[url] = site.id
+		uiView.Labels[url] = site.config.Label
+		uiView.Tags[url] = site.config.Tags
+		uiView.URLStatistics[url] = site.statistics
+		uiView.URLObjectives[url] = site.objectives
+		if site.watcher != nil {
+			uiView.Contents[url] = site.watcher
+		}
+	}
+	if uiView.ActiveWebsite >= len(uiView.Urls) {
+		uiView.ActiveWebsite = 0
+	}
+	// Keeping the active website within the scrolled list
+	uiView.MoveSelection(0)
+}
+
+// rebuildStatistics adds the records of a website in the history, under its key, to its statistics, objectives and anomaly detector.
+// Objectives are rebuilt from the rollups as well.
+func rebuildStatistics(store *history.Store, key string, site *website) error {
+	urlWindows := append([]*statistics.Statistic{site.alertStatistic}, site.statistics...)
+	// Reading the records of the longest timeframe
+	var longest time.Duration
+	for _, statistic := range urlWindows {
+		if statistic.Window() > longest {
+			longest = statistic.Window()
+		}
+	}
+	for _, objective := range site.objectives {
+		if objective.Window() > longest {
+			longest = objective.Window()
+		}
+	}
+	rollups, err := store.Rollups(key, time.Now().Add(-longest), time.Now())
+	if err != nil {
+		return err
+	}
+	for _, rollup := range rollups {
+		for _, objective := range site.objectives {
+			// Within a rollup, the fastest response times are assumed to be the successful ones
+			good := rollup.StatusCodeCount[200]
+			if objective.Threshold > 0 && rollup.ResponseTimes.CountAtMost(objective.Threshold) < good {
+				good = rollup.ResponseTimes.CountAtMost(objective.Threshold)
+			}
+			objective.AddCount(rollup.Time, good, rollup.Count)
+		}
+	}
+	records, err := store.Records(key, time.Now().Add(-longest), time.Now())
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		for _, statistic := range urlWindows {
+			statistic.AddSample(statistics.Sample{Time: record.Time, ResponseTime: record.ResponseTime, StatusCode: record.StatusCode, Reason: record.Reason})
+		}
+		for _, objective := range site.objectives {
+			objective.AddRecord(record.Time, record.ResponseTime, record.StatusCode)
+		}
+		if site.detector != nil {
+			site.detector.AddRecord(record.Time, record.ResponseTime, record.StatusCode)
+		}
+	}
+	return nil
+}
+
+// restoreIncidents returns the incidents of a website recorded in the history under its key since a time, to be restored in the tracker,
+// along with the incident started before if it is ongoing.
+// Incidents ongoing when the monitor stopped are closed at the last recorded check, and recorded so.
+// Should the website still be down, its next checks open a new incident.
+func restoreIncidents(store *history.Store, url string, key string, since time.Time) ([]incidents.Incident, error) {
+	urlIncidents, err := store.Incidents(key, since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	last, found, err := store.LastIncident(key, since)
+	if err != nil {
+		return nil, err
+	}
+	if found && last.Ongoing() {
+		urlIncidents = append([]incidents.Incident{last}, urlIncidents...)
+	}
+	for i := range urlIncidents {
+		if urlIncidents[i].Ongoing() {
+			lastCheck, err := lastRecorded(store, key, urlIncidents[i].Start)
+			if err != nil {
+				return nil, err
+			}
+			urlIncidents[i].Interrupt(lastCheck)
+			store.SaveIncident(urlIncidents[i])
+		}
+		urlIncidents[i].URL = url
+	}
+	return urlIncidents, nil
+}
+
+// lastRecorded returns the time of the last check of a website recorded in the history under its key since a time, zero if there is none.
+// Records older than their retention are only found in the rollups, by their minute.
+func lastRecorded(store *history.Store, key string, since time.Time) (time.Time, error) {
+	records, err := store.Records(key, since, time.Now())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(records) > 0 {
+		return records[len(records)-1].Time, nil
+	}
+	rollups, err := store.Rollups(key, since, time.Now())
+	if err != nil || len(rollups) == 0 {
+		return time.Time{}, err
+	}
+	return rollups[len(rollups)-1].Time, nil
+}
+
+// handleCommand handles a management request of the API, and updates the UI accordingly.
+// Websites to add are loaded from the history meanwhile, and sent on the loaded channel once loaded : the request is answered then.
+func handleCommand(uiView *display.View, websites *registry, burning map[burnAlert]bool, loaded chan<- loadedWebsite, command api.Command) {
+	if command.Action == "add" {
+		if err := websites.addLater(command, loaded); err != nil {
+			command.Done <- err
+		}
+		return
+	}
+	command.Done <- handleChange(uiView, websites, burning, command)
+}
+
+// handleChange handles a management request of the API about a monitored website
+func handleChange(uiView *display.View, websites *registry, burning map[burnAlert]bool, command api.Command) error {
+	site, ok := websites.websites[command.URL]
+	if !ok {
+		return fmt.Errorf("website %s is not monitored", command.Website.Label)
+	}
+	switch command.Action {
+	case "pause":
+		websites.send(command.URL, monitor.Pause)
+	case "resume":
+		websites.send(command.URL, monitor.Resume)
+	case "check":
+		websites.send(command.URL, monitor.CheckNow)
+	case "remove":
+		// The UI always details a website
+		if len(websites.urls) == 1 {
+			return fmt.Errorf("website %s is the last monitored website", site.config.Label)
+		}
+		if incident, closed := websites.remove(command.URL); closed {
+			saveIncident(websites.store, site.config.HistoryKey(), incident)
+		}
+		for _, objective := range site.objectives {
+			delete(burning, burnAlert{objective, true})
+			delete(burning, burnAlert{objective, false})
+		}
+	default:
+		return fmt.Errorf("unknown action %s", command.Action)
+	}
+	if command.Action == "remove" {
+		refreshWebsites(uiView, websites)
+	}
+	return nil
+}
+
+// handleLoaded starts checking a website added through the API once it is loaded, and answers the request
+func handleLoaded(uiView *display.View, websites *registry, loaded loadedWebsite) {
+	delete(websites.loading, loaded.command.URL)
+	if loaded.err != nil {
+		loaded.command.Done <- loaded.err
+		return
+	}
+	websites.start(loaded.command.URL, loaded.site, loaded.incidents)
+	refreshWebsites(uiView, websites)
+	loaded.command.Done <- nil
+}
+
+// refreshWebsites shows the websites added or removed in the UI and the dashboard
+func refreshWebsites(uiView *display.View, websites *registry) {
+	websites.updateView(uiView)
+	if uiView.UIEnabled {
+		go display.RenderStats(*uiView, uiView.ActiveTimeframe)
+		go display.RenderSLOs(*uiView)
+	}
+	if websites.dashboard != nil {
+		websites.dashboard.Refresh()
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hugo-sv/webmonitor/api"
+	"github.com/hugo-sv/webmonitor/cli"
+	"github.com/hugo-sv/webmonitor/display"
+	"github.com/hugo-sv/webmonitor/history"
+	"github.com/hugo-sv/webmonitor/incidents"
+	"github.com/hugo-sv/webmonitor/monitor"
+)
+
+// openHistory opens a history file in a temporary directory, removed by the returned function
+func openHistory(t *testing.T) (*history.Store, func()) {
+	dir, _ := ioutil.TempDir("", "webmonitor")
+	store, err := history.Open(filepath.Join(dir, "history.db"), history.DefaultRetention)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Open returned %v", err)
+	}
+	return store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRestoreIncidents(t *testing.T) {
+	// Test if the incidents started within the window are restored, along with the ongoing one started before, interrupted
+	store, remove := openHistory(t)
+	defer remove()
+	now := time.Now()
+	key := "https://example.com/?key=${KEY}"
+	store.SaveIncident(incidents.Incident{URL: key, Start: now.Add(-5 * time.Hour), End: now.Add(-4 * time.Hour)})
+	store.SaveIncident(incidents.Incident{URL: key, Start: now.Add(-3 * time.Hour)})
+	store.SaveIncident(incidents.Incident{URL: key, Start: now.Add(-30 * time.Minute), End: now.Add(-20 * time.Minute)})
+	store.Append(history.Record{URL: key, Time: now.Add(-2 * time.Hour), ResponseTime: 100, StatusCode: 500})
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush returned %v", err)
+	}
+
+	urlIncidents, err := restoreIncidents(store, "https://example.com/?key=secret", key, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("restoreIncidents returned %v", err)
+	}
+	if len(urlIncidents) != 2 {
+		t.Fatalf("restoreIncidents returned %v incidents, want 2", len(urlIncidents))
+	}
+	if !urlIncidents[0].Start.Equal(now.Add(-3*time.Hour)) || !urlIncidents[0].End.Equal(now.Add(-2*time.Hour)) {
+		t.Errorf("restoreIncidents()[0] == %+v, want the ongoing incident interrupted at the last check", urlIncidents[0])
+	}
+	if !urlIncidents[1].Start.Equal(now.Add(-30 * time.Minute)) {
+		t.Errorf("restoreIncidents()[1].Start == %v, want %v", urlIncidents[1].Start, now.Add(-30*time.Minute))
+	}
+	for _, incident := range urlIncidents {
+		if incident.URL != "https://example.com/?key=secret" {
+			t.Errorf("Incident.URL == %v, want the monitored URL", incident.URL)
+		}
+	}
+
+	// The interrupted incident is recorded, and no longer restored once out of the window
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush returned %v", err)
+	}
+	urlIncidents, _ = restoreIncidents(store, "https://example.com/?key=secret", key, now.Add(-time.Hour))
+	if len(urlIncidents) != 1 {
+		t.Errorf("restoreIncidents returned %v incidents, want 1", len(urlIncidents))
+	}
+}
+
+// testRegistry returns a registry without history nor servers, its websites checked every minute
+func testRegistry() *registry {
+	return &registry{
+		timeframes:   []time.Duration{time.Minute},
+		timeout:      1,
+		statsMessage: make(chan monitor.CheckStats, 16),
+		tracker:      incidents.NewTracker(alertTimeframe),
+		websites:     make(map[string]*website),
+		loading:      make(map[string]bool),
+	}
+}
+
+// testWebsite returns the configuration of a website checked every minute
+func testWebsite(url string) cli.Website {
+	return cli.Website{URL: url, Interval: 60, Label: url}
+}
+
+func TestRegistry(t *testing.T) {
+	// Test if websites are added in order, under ids never reused, and removed
+	websites := testRegistry()
+	defer websites.stop()
+	for _, url := range []string{"https://a.example.com", "https://b.example.com"} {
+		if err := websites.add(url, testWebsite(url)); err != nil {
+			t.Fatalf("add(%v) returned %v", url, err)
+		}
+	}
+	if err := websites.add("https://a.example.com", testWebsite("https://a.example.com")); err == nil {
+		t.Errorf("add() of a monitored website returned no error")
+	}
+	websites.tracker.Open("https://a.example.com", time.Now())
+	if _, closed := websites.remove("https://a.example.com"); !closed {
+		t.Errorf("remove() closed no incident, want the ongoing one")
+	}
+	if err := websites.add("https://a.example.com", testWebsite("https://a.example.com")); err != nil {
+		t.Fatalf("add() of a removed website returned %v", err)
+	}
+	if len(websites.urls) != 2 || websites.urls[0] != "https://b.example.com" || websites.urls[1] != "https://a.example.com" {
+		t.Errorf("urls == %v, want the websites in the order they were added", websites.urls)
+	}
+	if id := websites.websites["https://a.example.com"].id; id != 2 {
+		t.Errorf("id == %v, want 2", id)
+	}
+	if got := websites.tracker.Incidents("https://a.example.com"); len(got) != 0 {
+		t.Errorf("Incidents() == %v, want the incidents of the removed website forgotten", got)
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	websites := testRegistry()
+	defer websites.stop()
+	if err := websites.add("https://a.example.com", testWebsite("https://a.example.com")); err != nil {
+		t.Fatalf("add returned %v", err)
+	}
+	uiView := &display.View{}
+	burning := make(map[burnAlert]bool)
+	loaded := make(chan loadedWebsite, 1)
+	command := func(action string, url string) api.Command {
+		return api.Command{Action: action, URL: url, Website: testWebsite(url), Done: make(chan error, 1)}
+	}
+
+	// Added websites are loaded meanwhile, and started once loaded
+	add := command("add", "https://b.example.com")
+	handleCommand(uiView, websites, burning, loaded, add)
+	if len(add.Done) != 0 {
+		t.Errorf("handleCommand answered %v before the website was loaded", <-add.Done)
+	}
+	duplicate := command("add", "https://b.example.com")
+	handleCommand(uiView, websites, burning, loaded, duplicate)
+	if err := <-duplicate.Done; err == nil {
+		t.Errorf("adding a website being loaded returned no error")
+	}
+	handleLoaded(uiView, websites, <-loaded)
+	if err := <-add.Done; err != nil {
+		t.Errorf("adding a website returned %v", err)
+	}
+	if len(uiView.Urls) != 2 || uiView.Labels["https://b.example.com"] != "https://b.example.com" {
+		t.Errorf("View.Urls == %v, want the added website shown", uiView.Urls)
+	}
+
+	tests := []struct {
+		action  string
+		url     string
+		wantErr bool
+	}{
+		{"pause", "https://c.example.com", true},
+		{"pause", "https://b.example.com", false},
+		{"resume", "https://b.example.com", false},
+		{"restart", "https://b.example.com", true},
+		{"remove", "https://b.example.com", false},
+		// The last website is kept
+		{"remove", "https://a.example.com", true},
+	}
+	for _, test := range tests {
+		command := command(test.action, test.url)
+		handleCommand(uiView, websites, burning, loaded, command)
+		if err := <-command.Done; (err != nil) != test.wantErr {
+			t.Errorf("%v of %v returned %v, want an error : %v", test.action, test.url, err, test.wantErr)
+		}
+	}
+	if len(uiView.Urls) != 1 || uiView.Urls[0] != "https://a.example.com" {
+		t.Errorf("View.Urls == %v, want the removed website hidden", uiView.Urls)
+	}
+}